@@ -1,13 +1,18 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 
 	"github.com/coffeemakingtoaster/whale-watcher/pkg/config"
 	"github.com/coffeemakingtoaster/whale-watcher/pkg/docs"
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/history"
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/rules"
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/schema"
 	"github.com/coffeemakingtoaster/whale-watcher/pkg/validator"
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/waivers"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
@@ -36,13 +41,13 @@ var rootCmd = &cobra.Command{
 
 		// 2️⃣ Try to read the config file
 		if err := viper.ReadInConfig(); err != nil {
-			if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-				fmt.Println("No config file found, using defaults/env/flags")
+			if _, ok := err.(viper.ConfigFileNotFoundError); ok || errors.Is(err, os.ErrNotExist) {
+				fmt.Fprintln(os.Stderr, "No config file found, using defaults/env/flags")
 			} else {
 				return fmt.Errorf("failed to read config file: %w", err)
 			}
 		} else {
-			fmt.Println("Using config file:", viper.ConfigFileUsed())
+			fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
 		}
 
 		// 3️⃣ Environment variable setup
@@ -57,6 +62,12 @@ var rootCmd = &cobra.Command{
 			return fmt.Errorf("failed to bind persistent flags: %w", err)
 		}
 
+		if viper.GetBool("strict") && viper.ConfigFileUsed() != "" {
+			if err := config.CheckUnknownFields(viper.ConfigFileUsed()); err != nil {
+				return err
+			}
+		}
+
 		return nil
 	}}
 
@@ -116,8 +127,16 @@ func main() {
 	rootCmd.AddCommand(docs.NewCommand())
 	rootCmd.AddCommand(validator.NewCommand())
 	rootCmd.AddCommand(config.NewCommand())
+	rootCmd.AddCommand(rules.NewCommand())
+	rootCmd.AddCommand(schema.NewCommand())
+	rootCmd.AddCommand(waivers.NewCommand())
+	rootCmd.AddCommand(history.NewCommand())
 
 	if err := rootCmd.Execute(); err != nil {
+		var exitErr interface{ ExitCode() int }
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.ExitCode())
+		}
 		os.Exit(1)
 	}
 }
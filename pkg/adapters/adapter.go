@@ -33,9 +33,9 @@ func GetAdapterForRepository(repository string) (PullRequestAdapter, error) {
 	return &github.GithubPullRequestAdapter{}, errors.New("No configured vsc matched")
 }
 
-func CreatePRForFixes(violations violationTypes.Violations, updatedDockerfilePath string) error {
+func CreatePRForFixes(violations violationTypes.Violations, updatedDockerfilePath string, info violationTypes.PullRequestInfo) error {
 	// No fixes -> No Pr
-	if len(violations.Violations) == 0 {
+	if violations.ViolationCount == 0 {
 		log.Debug().Msg("No violations in current run, skipping PR creation")
 		return nil
 	}
@@ -63,6 +63,10 @@ func CreatePRForFixes(violations violationTypes.Violations, updatedDockerfilePat
 		return nil
 	}
 
-	err = adapter.CreatePullRequest(newBranch, viper.GetString("target.branch"), "Autofixes", violations.BuildDescriptionMarkdown())
-	return err
+	title, body, err := violations.BuildPullRequest(info)
+	if err != nil {
+		return err
+	}
+
+	return adapter.CreatePullRequest(newBranch, viper.GetString("target.branch"), title, body)
 }
@@ -5,7 +5,6 @@ import (
 	"reflect"
 	"strings"
 
-	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -132,6 +131,12 @@ func addFieldFlag(flagSet *pflag.FlagSet, prefix string, field *reflect.StructFi
 
 	desc := field.Tag.Get("desc")
 
+	// Flag is registered by the command that uses the value
+	if field.Tag.Get("flag") == "-" {
+		_ = viper.BindEnv(fullKey, envVar)
+		return nil
+	}
+
 	switch value.Kind() {
 	case reflect.String:
 		flagSet.String(fullKey, "", fmt.Sprintf("%s - (string) Env: %s", desc, envVar))
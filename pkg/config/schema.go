@@ -0,0 +1,72 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/util"
+	"gopkg.in/yaml.v3"
+)
+
+// JSON schema of the config file, generated from the config types
+func GetConfigSchema() *util.JSONSchema {
+	schema := util.GenerateJSONSchema(reflect.TypeOf(Config{}), "mapstructure")
+	schema.Schema = util.JSONSchemaDraft
+	schema.Title = "whale-watcher config"
+	schema.Properties["fail_on"].Enum = []string{"", "info", "low", "medium", "high", "critical"}
+	return schema
+}
+
+// Check the config file for fields that are not part of the config
+func CheckUnknownFields(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var document yaml.Node
+	if err = yaml.Unmarshal(data, &document); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if len(document.Content) == 0 {
+		return nil
+	}
+	unknown := findUnknownFields(document.Content[0], reflect.TypeOf(Config{}), "")
+	if len(unknown) == 0 {
+		return nil
+	}
+	for i := range unknown {
+		unknown[i] = fmt.Sprintf("%s:%s", path, unknown[i])
+	}
+	return fmt.Errorf("Unknown fields in config:\n%s", strings.Join(unknown, "\n"))
+}
+
+func findUnknownFields(node *yaml.Node, t reflect.Type, prefix string) []string {
+	if node.Kind != yaml.MappingNode || t.Kind() != reflect.Struct {
+		return nil
+	}
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		mapKey := t.Field(i).Tag.Get("mapstructure")
+		if mapKey == "" {
+			mapKey = strings.ToLower(t.Field(i).Name)
+		}
+		fields[mapKey] = t.Field(i).Type
+	}
+	unknown := []string{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		fieldType, ok := fields[key.Value]
+		if !ok {
+			unknown = append(unknown, fmt.Sprintf("%d: unknown field %s%s", key.Line, prefix, key.Value))
+			continue
+		}
+		unknown = append(unknown, findUnknownFields(node.Content[i+1], fieldType, prefix+key.Value+".")...)
+	}
+	return unknown
+}
@@ -0,0 +1,48 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/config"
+)
+
+func TestCheckUnknownFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `target:
+  dockerfile: ./Dockerfile
+  imgae: ubuntu
+github:
+  pat: abc
+no_fixx: true
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := config.CheckUnknownFields(path)
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	for _, expected := range []string{path + ":3: unknown field target.imgae", path + ":6: unknown field no_fixx"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Error mismatch: Expected '%s' in '%s'", expected, err.Error())
+		}
+	}
+}
+
+func TestCheckKnownFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `target:
+  dockerfile: ./Dockerfile
+  insecure: true
+fail_on: high
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.CheckUnknownFields(path); err != nil {
+		t.Errorf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+}
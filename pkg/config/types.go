@@ -54,12 +54,33 @@ func ValidateGitea() error {
 	return nil
 }
 
+type PullRequestConfig struct {
+	Title    string `mapstructure:"title" env:"TITLE" desc:"Go template for the title of pull requests with fixes (default: Autofixes)"`
+	Template string `mapstructure:"template" env:"TEMPLATE" desc:"Path of a Go template file for the description of pull requests with fixes"`
+}
+
 type Config struct {
-	Github     GithubConfig `mapstructure:"github" envPrefix:"GITHUB_" group:"Github Config"`
-	Gitea      GiteaConfig  `mapstructure:"gitea" envPrefix:"GITEA_" group:"Gitea Config"`
-	Target     TargetConfig `mapstructure:"target" envPrefix:"TARGET_" group:"Target Config"`
-	TargetList string       `mapstructure:"target_list" env:"TARGET_LIST" desc:"List all allowed targets"`
-	LogLevel   int          `mapstructure:"log_level" env:"LOG_LEVEL" desc:"Set log level (1-5)"`
-	DocsURL    string       `mapstructure:"docs_url" env:"DOCS_URL" desc:"Url pointing to active deployment of policy set documentation"`
-	NoFix      bool         `mapstructure:"no_fix" env:"NO_FIX" desc:"Disable the fixing functionality for detected violations"`
+	Github         GithubConfig      `mapstructure:"github" envPrefix:"GITHUB_" group:"Github Config"`
+	Gitea          GiteaConfig       `mapstructure:"gitea" envPrefix:"GITEA_" group:"Gitea Config"`
+	Target         TargetConfig      `mapstructure:"target" envPrefix:"TARGET_" group:"Target Config"`
+	PullRequest    PullRequestConfig `mapstructure:"pull_request" envPrefix:"PULL_REQUEST_" group:"Pull Request Config"`
+	TargetList     string            `mapstructure:"target_list" env:"TARGET_LIST" desc:"List all allowed targets"`
+	LogLevel       int               `mapstructure:"log_level" env:"LOG_LEVEL" desc:"Set log level (1-5)"`
+	DocsURL        string            `mapstructure:"docs_url" env:"DOCS_URL" desc:"Url pointing to active deployment of policy set documentation"`
+	NoFix          bool              `mapstructure:"no_fix" env:"NO_FIX" desc:"Disable the fixing functionality for detected violations"`
+	StrictIncludes bool              `mapstructure:"strict_includes" env:"STRICT_INCLUDES" desc:"Fail if an included ruleset cannot be loaded instead of skipping it"`
+	Strict         bool              `mapstructure:"strict" env:"STRICT" desc:"Reject unknown fields in rulesets and the config file"`
+	// Exposed via the --fail-on flag of validate
+	FailOn string `mapstructure:"fail_on" env:"FAIL_ON" flag:"-" desc:"Lowest severity (info, low, medium, high, critical) that fails the run. If empty every violation fails the run"`
+	// Exposed via the --fail-on-error flag of validate
+	FailOnError bool `mapstructure:"fail_on_error" env:"FAIL_ON_ERROR" flag:"-" desc:"Fail the run if a rule could not be evaluated (e.g. a util panicked). Violations take precedence for the exit code"`
+	// Exposed via the --waivers flag of validate
+	Waivers string `mapstructure:"waivers" env:"WAIVERS" flag:"-" desc:"Path of the waiver file (default: .whale-watcher-waivers.yaml)"`
+	// Exposed via the --baseline flag of validate
+	Baseline string `mapstructure:"baseline" env:"BASELINE" flag:"-" desc:"Path of a baseline file, only violations that are not part of the baseline fail the run"`
+	// Exposed via the --lockfile flag of validate
+	Lockfile string `mapstructure:"lockfile" env:"LOCKFILE" flag:"-" desc:"Path of the lockfile that pins remote rulesets (default: the lockfile beside a local ruleset)"`
+	DataDir  string `mapstructure:"data_dir" env:"DATA_DIR" desc:"Directory of the run history (default: $XDG_DATA_HOME/whale-watcher or ~/.local/share/whale-watcher)"`
+	// Exposed via the --no-history flag of validate
+	NoHistory bool `mapstructure:"no_history" env:"NO_HISTORY" flag:"-" desc:"Do not record the run in the run history"`
 }
@@ -1,26 +1,24 @@
 package container
 
 import (
+	"strings"
+
 	"github.com/coffeemakingtoaster/dockerfile-parser/pkg/ast"
 	"github.com/coffeemakingtoaster/dockerfile-parser/pkg/lexer"
 	"github.com/coffeemakingtoaster/dockerfile-parser/pkg/parser"
+	"github.com/coffeemakingtoaster/dockerfile-parser/pkg/util"
 )
 
 func GetDockerfileAST(path string) (*ast.StageNode, error) {
-	l, err := lexer.NewFromFile(path)
+	lines, err := util.ReadFileLines(path)
 	if err != nil {
 		return nil, err
 	}
-	tokens, err := l.Lex()
-	if err != nil {
-		return nil, err
-	}
-	p := parser.NewParser(tokens)
-	return p.Parse(), nil
+	return GetDockerfileInputAST(lines)
 }
 
 func GetDockerfileInputAST(input []string) (*ast.StageNode, error) {
-	l := lexer.NewFromInput(input)
+	l := lexer.NewFromInput(applyEscapeDirective(input))
 	tokens, err := l.Lex()
 	if err != nil {
 		return nil, err
@@ -28,3 +26,42 @@ func GetDockerfileInputAST(input []string) (*ast.StageNode, error) {
 	p := parser.NewParser(tokens)
 	return p.Parse(), nil
 }
+
+// The lexer continues every line ending in \, including comments such as # escape=\, and does not know the escape directive
+// So continued lines are joined beforehand using the escape character of the Dockerfile
+func applyEscapeDirective(lines []string) []string {
+	escape := getEscapeCharacter(lines)
+	joined := []string{}
+	buffer := ""
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") {
+			// Comments within the instruction are dropped by docker
+			if len(buffer) > 0 {
+				continue
+			}
+			// Comments are never continued, parser directives are not part of the reconstructed Dockerfile
+			joined = append(joined, strings.TrimRight(trimmed, "\\"+escape))
+			continue
+		}
+		if strings.HasSuffix(trimmed, escape) {
+			buffer += strings.TrimSuffix(trimmed, escape)
+			continue
+		}
+		joined = append(joined, protectTrailingBackslash(buffer+trimmed, escape)...)
+		buffer = ""
+	}
+	if len(buffer) > 0 {
+		joined = append(joined, protectTrailingBackslash(buffer, escape)...)
+	}
+	return joined
+}
+
+// With another escape character a trailing \ is part of the instruction (e.g. WORKDIR C:\app\), but the lexer would still continue the line
+// The lexer drops one \ and continues with the empty line, which ends the instruction and keeps the original \
+func protectTrailingBackslash(line, escape string) []string {
+	if escape == "\\" || !strings.HasSuffix(line, "\\") {
+		return []string{line}
+	}
+	return []string{line + "\\", ""}
+}
@@ -18,6 +18,23 @@ type ContainerImage struct {
 	OciPath  string
 }
 
+// Digest of the image manifest, identifies the image regardless of its tags
+// Only the index is read rather than loading the whole image
+func GetImageDigest(ociPath string) (string, error) {
+	raw, err := tarutils.GetBlobFromPathByName(ociPath, "index.json")
+	if err != nil {
+		return "", err
+	}
+	imageIndex, err := tarutils.ParseJsonBytesIntoInterface[OCIImageIndex](raw)
+	if err != nil {
+		return "", err
+	}
+	if len(imageIndex.Manifests) == 0 {
+		return "", fmt.Errorf("Image index of %s does not contain a manifest", ociPath)
+	}
+	return imageIndex.Manifests[0].Digest, nil
+}
+
 func ContainerImageFromOCITar(ociPath string) (*ContainerImage, error) {
 	loadedTar := tarutils.LoadTar(ociPath)
 
@@ -0,0 +1,153 @@
+package container
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/coffeemakingtoaster/dockerfile-parser/pkg/ast"
+	"github.com/coffeemakingtoaster/dockerfile-parser/pkg/token"
+)
+
+// Position of an instruction in the Dockerfile
+type InstructionLocation struct {
+	// 1 based, inclusive
+	StartLine int `json:"start_line"`
+	EndLine   int `json:"end_line"`
+	// Index of the stage the instruction belongs to, -1 for instructions before the first FROM
+	StageIndex int    `json:"stage_index"`
+	StageName  string `json:"stage_name"`
+	// Source text of the instruction including continuation lines
+	Instruction string `json:"instruction"`
+}
+
+var heredocPattern = regexp.MustCompile(`<<-?\s*["']?([A-Za-z0-9_]+)["']?`)
+
+// Parser directives (# directive=value) have to precede everything else in the Dockerfile
+var directivePattern = regexp.MustCompile(`^#\s*([A-Za-z]+)\s*=\s*(\S*)\s*$`)
+
+// Find the location of the node by pairing the instructions of the ast with the instructions in the source lines
+// The ast does not keep line numbers, so both are walked in order ignoring comments and empty lines
+func LocateNode(root *ast.StageNode, lines []string, node ast.Node) (InstructionLocation, error) {
+	sourceInstructions := GetInstructionLocations(lines)
+	// Pairing source and ast that disagree would attribute every later node to the wrong lines
+	if count := countInstructions(root); count != len(sourceInstructions) {
+		return InstructionLocation{}, fmt.Errorf("Could not map the Dockerfile to its source, found %d instructions in the source and %d in the ast", len(sourceInstructions), count)
+	}
+	index := 0
+	stageIndex := -1
+	stageName := ""
+	for stage := root; stage != nil; stage = stage.Subsequent {
+		if stage != root {
+			stageIndex++
+			stageName = stage.Name
+			if node == ast.Node(stage) {
+				return buildLocation(sourceInstructions, index, stageIndex, stageName)
+			}
+			index++
+		}
+		for _, instruction := range stage.Instructions {
+			switch instruction.(type) {
+			case *ast.CommentInstructionNode, *ast.EmptyLineNode:
+				continue
+			}
+			if node == ast.Node(instruction) {
+				return buildLocation(sourceInstructions, index, stageIndex, stageName)
+			}
+			index++
+		}
+	}
+	return InstructionLocation{}, errors.New("Node is not part of the Dockerfile")
+}
+
+func countInstructions(root *ast.StageNode) int {
+	count := 0
+	for stage := root; stage != nil; stage = stage.Subsequent {
+		if stage != root {
+			count++
+		}
+		for _, instruction := range stage.Instructions {
+			switch instruction.(type) {
+			case *ast.CommentInstructionNode, *ast.EmptyLineNode:
+				continue
+			}
+			count++
+		}
+	}
+	return count
+}
+
+func buildLocation(sourceInstructions []InstructionLocation, index, stageIndex int, stageName string) (InstructionLocation, error) {
+	if index >= len(sourceInstructions) {
+		return InstructionLocation{}, errors.New("Could not map node to a line of the Dockerfile")
+	}
+	location := sourceInstructions[index]
+	location.StageIndex = stageIndex
+	location.StageName = stageName
+	return location, nil
+}
+
+// Split the source lines into instructions with their line range
+// Continuation lines (using the escape character of the Dockerfile), comments within instructions and heredocs are part of the instruction
+func GetInstructionLocations(lines []string) []InstructionLocation {
+	instructions := []InstructionLocation{}
+	escape := getEscapeCharacter(lines)
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if len(line) == 0 || strings.HasPrefix(line, "#") || !isInstruction(line) {
+			continue
+		}
+		start := i
+		for strings.HasSuffix(strings.TrimSpace(lines[i]), escape) && i+1 < len(lines) {
+			i++
+			// Comments within the instruction are dropped by docker
+			for i+1 < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), "#") {
+				i++
+			}
+		}
+		if match := heredocPattern.FindStringSubmatch(line); match != nil && isHeredocInstruction(line) {
+			for i+1 < len(lines) && strings.TrimSpace(lines[i]) != match[1] {
+				i++
+			}
+		}
+		instructions = append(instructions, InstructionLocation{
+			StartLine:   start + 1,
+			EndLine:     i + 1,
+			Instruction: strings.Join(lines[start:i+1], "\n"),
+		})
+	}
+	return instructions
+}
+
+func isInstruction(line string) bool {
+	kind, ok := token.TokenLookupTable[getKeyword(line)]
+	return ok && kind != token.ILLEGAL && kind != token.EOF
+}
+
+func isHeredocInstruction(line string) bool {
+	keyword := getKeyword(line)
+	return keyword == "RUN" || keyword == "COPY" || keyword == "ADD"
+}
+
+// Escape character set using the escape parser directive, \ by default
+func getEscapeCharacter(lines []string) string {
+	for _, line := range lines {
+		match := directivePattern.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			break
+		}
+		if strings.EqualFold(match[1], "escape") && len(match[2]) == 1 {
+			return match[2]
+		}
+	}
+	return "\\"
+}
+
+func getKeyword(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToUpper(fields[0])
+}
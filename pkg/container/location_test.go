@@ -0,0 +1,207 @@
+package container_test
+
+import (
+	"testing"
+
+	"github.com/coffeemakingtoaster/dockerfile-parser/pkg/ast"
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/container"
+)
+
+var locationDockerfile = []string{
+	"# syntax=docker/dockerfile:1",
+	"ARG VERSION=1.24",
+	"FROM golang:${VERSION} AS build",
+	"",
+	"# Install deps",
+	"RUN apt-get update && \\",
+	"    # comment within the instruction",
+	"    apt-get install -y curl",
+	"RUN <<EOF",
+	"echo hello",
+	"EOF",
+	"",
+	"FROM debian:trixie",
+	"COPY --from=build /app /app",
+}
+
+func TestLocateNode(t *testing.T) {
+	root, err := container.GetDockerfileInputAST(locationDockerfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	build := root.Subsequent
+	runtime := build.Subsequent
+	instructions := []ast.InstructionNode{}
+	for _, instruction := range build.Instructions {
+		switch instruction.(type) {
+		case *ast.RunInstructionNode:
+			instructions = append(instructions, instruction)
+		}
+	}
+	if len(instructions) != 2 {
+		t.Fatalf("Instruction count mismatch: Expected 2 Got %d", len(instructions))
+	}
+	expected := []struct {
+		node     ast.Node
+		expected container.InstructionLocation
+	}{
+		{root.Instructions[0], container.InstructionLocation{StartLine: 2, EndLine: 2, StageIndex: -1, Instruction: "ARG VERSION=1.24"}},
+		{build, container.InstructionLocation{StartLine: 3, EndLine: 3, StageIndex: 0, StageName: "build", Instruction: "FROM golang:${VERSION} AS build"}},
+		{instructions[0], container.InstructionLocation{StartLine: 6, EndLine: 8, StageIndex: 0, StageName: "build", Instruction: "RUN apt-get update && \\\n    # comment within the instruction\n    apt-get install -y curl"}},
+		{instructions[1], container.InstructionLocation{StartLine: 9, EndLine: 11, StageIndex: 0, StageName: "build", Instruction: "RUN <<EOF\necho hello\nEOF"}},
+		{runtime.Instructions[0], container.InstructionLocation{StartLine: 14, EndLine: 14, StageIndex: 1, Instruction: "COPY --from=build /app /app"}},
+	}
+	for _, testCase := range expected {
+		actual, err := container.LocateNode(root, locationDockerfile, testCase.node)
+		if err != nil {
+			t.Errorf("Error mismatch: Expected nil Got '%s'", err.Error())
+			continue
+		}
+		if actual != testCase.expected {
+			t.Errorf("Location mismatch: Expected %+v Got %+v", testCase.expected, actual)
+		}
+	}
+}
+
+func TestLocateForeignNode(t *testing.T) {
+	root, err := container.GetDockerfileInputAST(locationDockerfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := container.LocateNode(root, locationDockerfile, &ast.RunInstructionNode{}); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+func TestLocateNodeEscapeDirective(t *testing.T) {
+	lines := []string{
+		"# escape=`",
+		"FROM mcr.microsoft.com/windows/servercore AS build",
+		"RUN echo a `",
+		"    && echo b",
+		"COPY app/ c:/app/",
+	}
+	root, err := container.GetDockerfileInputAST(lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	build := root.Subsequent
+	if len(build.Instructions) < 2 {
+		t.Fatalf("Instruction count mismatch: Expected at least 2 Got %d", len(build.Instructions))
+	}
+	expected := []struct {
+		node     ast.Node
+		expected container.InstructionLocation
+	}{
+		{build.Instructions[0], container.InstructionLocation{StartLine: 3, EndLine: 4, StageIndex: 0, StageName: "build", Instruction: "RUN echo a `\n    && echo b"}},
+		{build.Instructions[1], container.InstructionLocation{StartLine: 5, EndLine: 5, StageIndex: 0, StageName: "build", Instruction: "COPY app/ c:/app/"}},
+	}
+	for _, testCase := range expected {
+		actual, err := container.LocateNode(root, lines, testCase.node)
+		if err != nil {
+			t.Errorf("Error mismatch: Expected nil Got '%s'", err.Error())
+			continue
+		}
+		if actual != testCase.expected {
+			t.Errorf("Location mismatch: Expected %+v Got %+v", testCase.expected, actual)
+		}
+	}
+}
+
+func TestLocateNodeEscapeDirectiveTrailingBackslash(t *testing.T) {
+	lines := []string{
+		"# escape=`",
+		"FROM mcr.microsoft.com/windows/servercore AS build",
+		"WORKDIR C:\\app\\",
+		"COPY app/ c:/app/",
+		"RUN dir C:\\",
+	}
+	root, err := container.GetDockerfileInputAST(lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	build := root.Subsequent
+	if len(build.Instructions) != 3 {
+		t.Fatalf("Instruction count mismatch: Expected 3 Got %d", len(build.Instructions))
+	}
+	workdir, ok := build.Instructions[0].(*ast.WorkdirInstructionNode)
+	if !ok {
+		t.Fatalf("Instruction type mismatch: Expected *ast.WorkdirInstructionNode Got %T", build.Instructions[0])
+	}
+	if workdir.Path != "C:\\app\\" {
+		t.Errorf("Path mismatch: Expected 'C:\\app\\' Got '%s'", workdir.Path)
+	}
+	expected := []struct {
+		node     ast.Node
+		expected container.InstructionLocation
+	}{
+		{build.Instructions[0], container.InstructionLocation{StartLine: 3, EndLine: 3, StageIndex: 0, StageName: "build", Instruction: "WORKDIR C:\\app\\"}},
+		{build.Instructions[1], container.InstructionLocation{StartLine: 4, EndLine: 4, StageIndex: 0, StageName: "build", Instruction: "COPY app/ c:/app/"}},
+		{build.Instructions[2], container.InstructionLocation{StartLine: 5, EndLine: 5, StageIndex: 0, StageName: "build", Instruction: "RUN dir C:\\"}},
+	}
+	for _, testCase := range expected {
+		actual, err := container.LocateNode(root, lines, testCase.node)
+		if err != nil {
+			t.Errorf("Error mismatch: Expected nil Got '%s'", err.Error())
+			continue
+		}
+		if actual != testCase.expected {
+			t.Errorf("Location mismatch: Expected %+v Got %+v", testCase.expected, actual)
+		}
+	}
+}
+
+func TestLocateNodeDefaultEscapeDirective(t *testing.T) {
+	lines := []string{
+		"# escape=\\",
+		"FROM debian:trixie AS build",
+		"RUN echo a \\",
+		"    && echo b",
+	}
+	root, err := container.GetDockerfileInputAST(lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The directive must not continue into the FROM instruction
+	if root.Subsequent == nil || root.Subsequent.Name != "build" {
+		t.Fatalf("Stage mismatch: Expected stage build Got %+v", root.Subsequent)
+	}
+	actual, err := container.LocateNode(root, lines, root.Subsequent.Instructions[0])
+	if err != nil {
+		t.Fatalf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+	if actual.StartLine != 3 || actual.EndLine != 4 {
+		t.Errorf("Location mismatch: Expected lines 3-4 Got %d-%d", actual.StartLine, actual.EndLine)
+	}
+}
+
+func TestGetInstructionLocationsAddHeredoc(t *testing.T) {
+	lines := []string{
+		"FROM debian:trixie",
+		"ADD <<EOF /etc/motd",
+		"RUN is not an instruction here",
+		"EOF",
+		"RUN echo hello",
+	}
+	actual := container.GetInstructionLocations(lines)
+	if len(actual) != 3 {
+		t.Fatalf("Instruction count mismatch: Expected 3 Got %d", len(actual))
+	}
+	if actual[1].StartLine != 2 || actual[1].EndLine != 4 {
+		t.Errorf("Location mismatch: Expected ADD at lines 2-4 Got %d-%d", actual[1].StartLine, actual[1].EndLine)
+	}
+	if actual[2].StartLine != 5 {
+		t.Errorf("Location mismatch: Expected RUN at line 5 Got %d", actual[2].StartLine)
+	}
+}
+
+func TestLocateNodeMismatchedSource(t *testing.T) {
+	root, err := container.GetDockerfileInputAST(locationDockerfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Source lines that do not belong to the ast must not produce wrong locations
+	if _, err := container.LocateNode(root, locationDockerfile[:10], root.Subsequent); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
@@ -35,6 +35,7 @@ func getBlobByPattern(reader *tar.Reader, searchValue string, headerNameTransfor
 			if err == io.EOF {
 				return []byte{}, ValueNotFound{digest: searchValue, tarPath: "in place"}
 			}
+			return []byte{}, err
 		}
 		if headerNameTransformer(header.Name) == searchValue {
 			break
@@ -55,6 +56,15 @@ func GetBlobFromPathByDigest(path string, digest string) ([]byte, error) {
 	return getBlobByPattern(tar.NewReader(f), digest, nameToBlobDigest)
 }
 
+func GetBlobFromPathByName(path string, name string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return []byte{}, err
+	}
+	defer f.Close()
+	return getBlobByPattern(tar.NewReader(f), name, func(s string) string { return s })
+}
+
 func GetBlobFromDataByDigest(data []byte, digest string) ([]byte, error) {
 	reader := bytes.NewReader(data)
 	return getBlobByPattern(tar.NewReader(reader), digest, nameToBlobDigest)
@@ -13,6 +13,8 @@ func NewCommand() *cobra.Command {
 	var export bool
 	var servePort int64
 	var exportPath *string
+	var only []string
+	var skip []string
 
 	var cmd = &cobra.Command{
 		Use:   "docs [flags] <policyset>",
@@ -32,6 +34,9 @@ Expected arguments:  <policy set location>
 			if err != nil {
 				panic(err)
 			}
+			if err = ruleSet.Filter(only, skip); err != nil {
+				panic(err)
+			}
 			serveRules(ruleSet, export, *exportPath, servePort)
 		},
 	}
@@ -44,6 +49,10 @@ Expected arguments:  <policy set location>
 	docFlags.SetAnnotation("file", "group", []string{docFlags.Name()})
 	docFlags.Int64VarP(&servePort, "port", "p", 3000, "Set the port for the webserver")
 	docFlags.SetAnnotation("port", "group", []string{docFlags.Name()})
+	docFlags.StringArrayVar(&only, "only", []string{}, "Only document rules matching the selector (rule id, glob or tag expression like 'tag:security && !tag:slow'). Can be repeated")
+	docFlags.SetAnnotation("only", "group", []string{docFlags.Name()})
+	docFlags.StringArrayVar(&skip, "skip", []string{}, "Skip rules matching the selector (rule id, glob or tag expression). Can be repeated")
+	docFlags.SetAnnotation("skip", "group", []string{docFlags.Name()})
 
 	cmd.Flags().AddFlagSet(docFlags)
 
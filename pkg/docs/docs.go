@@ -15,6 +15,11 @@ import (
 //go:embed site.tmpl
 var siteTemplate string
 
+// Styling of the docs site, shared with the html validation report
+//
+//go:embed style.css
+var Stylesheet string
+
 func serveRules(ruleSet rules.RuleSet, onlyExport bool, exportPath string, servePort int64) {
 	if onlyExport {
 		generateHTML(ruleSet, exportPath)
@@ -35,7 +40,7 @@ func serveRules(ruleSet rules.RuleSet, onlyExport bool, exportPath string, serve
 }
 
 func render(w io.Writer, ruleSet rules.RuleSet) {
-	tmpl, err := template.New("site").Parse(siteTemplate)
+	tmpl, err := template.New("site").Funcs(template.FuncMap{"stylesheet": func() string { return Stylesheet }}).Parse(siteTemplate)
 	if err != nil {
 		panic(err)
 	}
@@ -2,7 +2,6 @@ package fetcher
 
 import (
 	"archive/tar"
-	"bytes"
 	"fmt"
 	"io"
 	"os"
@@ -254,50 +253,83 @@ func loadDockerfileFromRepository(repositoryURL, branch, dockerfilePath string)
 	return loadedPath, nil
 }
 
+// Retrieve a file from the branch of the repository, the default branch is used if branch is empty
 func GetFileFromRepository(repositoryURL, branch, path string) ([]byte, error) {
+	if len(branch) == 0 {
+		branch = "HEAD"
+	}
+	data, _, err := GetFileFromRepositoryAtRevision(repositoryURL, branch, path)
+	return data, err
+}
+
+// Retrieve a file from the repository at the given revision
+// The revision may be a branch, tag or commit hash. The resolved commit hash is returned alongside the file data
+func GetFileFromRepositoryAtRevision(repositoryURL, revision, path string) ([]byte, string, error) {
 	fs := memfs.New()
 	storer := memory.NewStorage()
 
-	log.Debug().Str("url", repositoryURL).Msg("Cloning Repository")
+	log.Debug().Str("url", repositoryURL).Str("revision", revision).Msg("Cloning Repository")
 
 	repository, err := git.Clone(
 		storer,
 		fs,
-		&git.CloneOptions{URL: repositoryURL},
+		&git.CloneOptions{URL: repositoryURL, NoCheckout: true, Tags: git.AllTags},
 	)
+	if err != nil {
+		return []byte{}, "", err
+	}
 
+	hash, err := resolveRevision(repository, revision)
 	if err != nil {
-		return []byte{}, err
+		log.Error().Str("revision", revision).Msg("Could not resolve revision")
+		return []byte{}, "", err
 	}
+
 	w, err := repository.Worktree()
 	if err != nil {
-		return []byte{}, err
+		return []byte{}, "", err
 	}
-	dockerfileDirectory := filepath.Dir(path)
-
-	branchReference := plumbing.NewBranchReferenceName(branch)
 
-	log.Debug().Str("path", path).Msg("Getting file")
-
-	err = w.Checkout(&git.CheckoutOptions{SparseCheckoutDirectories: []string{dockerfileDirectory}, Branch: plumbing.ReferenceName(branchReference)})
+	checkoutOptions := &git.CheckoutOptions{Hash: hash}
+	// A sparse checkout of "." leaves out the files at the root of the repository
+	if dir := filepath.Dir(path); dir != "." {
+		checkoutOptions.SparseCheckoutDirectories = []string{dir}
+	}
+	err = w.Checkout(checkoutOptions)
 	if err != nil {
 		log.Error().Msg("Could not checkout")
-		return []byte{}, err
+		return []byte{}, "", err
 	}
 
-	log.Debug().Str("path", path).Msg("Reading file")
+	log.Debug().Str("path", path).Str("commit", hash.String()).Msg("Reading file")
 
 	fileHandle, err := w.Filesystem.Open(path)
 	if err != nil {
 		log.Error().Str("path", path).Msg("Could not open file in worktree")
-		return []byte{}, err
+		return []byte{}, "", err
 	}
+	defer fileHandle.Close()
 
-	var data bytes.Buffer
-	_, err = io.Copy(&data, fileHandle)
+	data, err := io.ReadAll(fileHandle)
 	if err != nil {
-		log.Error().Msg("Could not copy file data to buffer")
-		return []byte{}, err
+		log.Error().Msg("Could not read file data")
+		return []byte{}, "", err
+	}
+	return data, hash.String(), nil
+}
+
+// Branches only exist as remote references after cloning, so these are tried as a fallback
+func resolveRevision(repository *git.Repository, revision string) (plumbing.Hash, error) {
+	if plumbing.IsHash(revision) {
+		return plumbing.NewHash(revision), nil
+	}
+	hash, err := repository.ResolveRevision(plumbing.Revision(revision))
+	if err == nil {
+		return *hash, nil
+	}
+	hash, remoteErr := repository.ResolveRevision(plumbing.Revision(plumbing.NewRemoteReferenceName("origin", revision)))
+	if remoteErr == nil {
+		return *hash, nil
 	}
-	return data.Bytes(), nil
+	return plumbing.ZeroHash, fmt.Errorf("Could not resolve revision %s: %w", revision, err)
 }
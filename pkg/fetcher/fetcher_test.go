@@ -0,0 +1,118 @@
+package fetcher_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/fetcher"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func commitFile(t *testing.T, repository *git.Repository, dir, path, content string) plumbing.Hash {
+	t.Helper()
+	w, err := repository.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, path), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Add(path); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := w.Commit(content, &git.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func TestGetFileFromRepositoryAtRevision(t *testing.T) {
+	dir := t.TempDir()
+	repository, err := git.PlainInitWithOptions(dir, &git.PlainInitOptions{InitOptions: git.InitOptions{DefaultBranch: plumbing.NewBranchReferenceName("main")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	firstCommit := commitFile(t, repository, dir, "policies/base.yaml", "first")
+	if _, err := repository.CreateTag("v1.0.0", firstCommit, &git.CreateTagOptions{Message: "v1.0.0", Tagger: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}}); err != nil {
+		t.Fatal(err)
+	}
+	w, err := repository.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("release"), Create: true}); err != nil {
+		t.Fatal(err)
+	}
+	releaseCommit := commitFile(t, repository, dir, "policies/base.yaml", "release")
+	if err := w.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("main")}); err != nil {
+		t.Fatal(err)
+	}
+	mainCommit := commitFile(t, repository, dir, "policies/base.yaml", "main")
+
+	expected := map[string]struct {
+		content string
+		commit  plumbing.Hash
+	}{
+		"main":                 {"main", mainCommit},
+		"release":              {"release", releaseCommit},
+		"v1.0.0":               {"first", firstCommit},
+		firstCommit.String():   {"first", firstCommit},
+		releaseCommit.String(): {"release", releaseCommit},
+	}
+	for revision, expectedResult := range expected {
+		data, commit, err := fetcher.GetFileFromRepositoryAtRevision(dir, revision, "policies/base.yaml")
+		if err != nil {
+			t.Errorf("Error mismatch for %s: Expected nil Got '%s'", revision, err.Error())
+			continue
+		}
+		if string(data) != expectedResult.content {
+			t.Errorf("Content mismatch for %s: Expected %s Got %s", revision, expectedResult.content, string(data))
+		}
+		if commit != expectedResult.commit.String() {
+			t.Errorf("Commit mismatch for %s: Expected %s Got %s", revision, expectedResult.commit.String(), commit)
+		}
+	}
+
+	if _, _, err := fetcher.GetFileFromRepositoryAtRevision(dir, "does-not-exist", "policies/base.yaml"); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+func TestGetFileFromRepository(t *testing.T) {
+	dir := t.TempDir()
+	repository, err := git.PlainInitWithOptions(dir, &git.PlainInitOptions{InitOptions: git.InitOptions{DefaultBranch: plumbing.NewBranchReferenceName("main")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, repository, dir, "Dockerfile", "FROM main")
+	w, err := repository.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("feature"), Create: true}); err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, repository, dir, "Dockerfile", "FROM feature")
+	if err := w.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("main")}); err != nil {
+		t.Fatal(err)
+	}
+
+	for branch, expected := range map[string]string{"": "FROM main", "main": "FROM main", "feature": "FROM feature"} {
+		data, err := fetcher.GetFileFromRepository(dir, branch, "Dockerfile")
+		if err != nil {
+			t.Errorf("Error mismatch for '%s': Expected nil Got '%s'", branch, err.Error())
+			continue
+		}
+		if string(data) != expected {
+			t.Errorf("Content mismatch for '%s': Expected %s Got %s", branch, expected, string(data))
+		}
+	}
+}
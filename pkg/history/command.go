@@ -0,0 +1,280 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func NewCommand() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "history",
+		Short: "Inspect recorded validation runs",
+		Long: `Tooling for the run history that validate records in the data directory (data_dir).
+Runs are grouped into targets by Dockerfile and repository.`,
+	}
+
+	cmd.AddCommand(newListCommand())
+	cmd.AddCommand(newTrendCommand())
+	cmd.AddCommand(newRuleCommand())
+
+	return cmd
+}
+
+func checkFormat(format string) error {
+	if format != "json" && format != "text" {
+		return fmt.Errorf("Unsupported format: %s (supported: 'json', 'text')", format)
+	}
+	return nil
+}
+
+func checkLimit(limit int) error {
+	if limit < 0 {
+		return fmt.Errorf("limit has to be positive (Got: %d)", limit)
+	}
+	return nil
+}
+
+// Runs of the target, an empty history is not an error for the listing commands
+func loadRuns(target string) ([]Run, error) {
+	runs, err := Load()
+	if errors.Is(err, ErrNoDatabase) {
+		return []Run{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return FilterByTarget(runs, target), nil
+}
+
+func newListCommand() *cobra.Command {
+	var format string
+	var target string
+	var limit int
+
+	var cmd = &cobra.Command{
+		Use:   "list",
+		Short: "List recorded runs, newest first",
+		Long:  `List the recorded validation runs with their outcome, newest first.`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				return fmt.Errorf("List takes no arguments (Got: '%s')", strings.Join(args, " "))
+			}
+			if err := checkFormat(format); err != nil {
+				return err
+			}
+			return checkLimit(limit)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			runs, err := loadRuns(target)
+			if err != nil {
+				return err
+			}
+			if len(runs) == 0 && format == "text" {
+				_, err = fmt.Fprintln(os.Stdout, "No recorded runs")
+				return err
+			}
+			return writeRunList(os.Stdout, listRuns(runs, limit), format)
+		},
+	}
+
+	listFlags := pflag.NewFlagSet("List Options", pflag.ExitOnError)
+
+	listFlags.StringVar(&format, "format", "text", "Output format (json, text)")
+	listFlags.SetAnnotation("format", "group", []string{listFlags.Name()})
+	listFlags.StringVar(&target, "target", "", "Only show runs of this target or Dockerfile")
+	listFlags.SetAnnotation("target", "group", []string{listFlags.Name()})
+	listFlags.IntVar(&limit, "limit", 20, "Maximum number of runs to show, 0 shows all runs")
+	listFlags.SetAnnotation("limit", "group", []string{listFlags.Name()})
+
+	cmd.Flags().AddFlagSet(listFlags)
+
+	return cmd
+}
+
+func newTrendCommand() *cobra.Command {
+	var format string
+	var target string
+	var limit int
+
+	var cmd = &cobra.Command{
+		Use:   "trend",
+		Short: "Show how the results of each target developed",
+		Long:  `Show the number of passed rules, violations and rule errors of the latest runs of each target, oldest first.`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				return fmt.Errorf("Trend takes no arguments (Got: '%s')", strings.Join(args, " "))
+			}
+			if err := checkFormat(format); err != nil {
+				return err
+			}
+			return checkLimit(limit)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			runs, err := loadRuns(target)
+			if err != nil {
+				return err
+			}
+			if len(runs) == 0 && format == "text" {
+				_, err = fmt.Fprintln(os.Stdout, "No recorded runs")
+				return err
+			}
+			return writeTrends(os.Stdout, GetTrends(runs, limit), format)
+		},
+	}
+
+	trendFlags := pflag.NewFlagSet("Trend Options", pflag.ExitOnError)
+
+	trendFlags.StringVar(&format, "format", "text", "Output format (json, text)")
+	trendFlags.SetAnnotation("format", "group", []string{trendFlags.Name()})
+	trendFlags.StringVar(&target, "target", "", "Only show the trend of this target or Dockerfile")
+	trendFlags.SetAnnotation("target", "group", []string{trendFlags.Name()})
+	trendFlags.IntVar(&limit, "limit", 10, "Number of runs shown per target, 0 shows all runs")
+	trendFlags.SetAnnotation("limit", "group", []string{trendFlags.Name()})
+
+	cmd.Flags().AddFlagSet(trendFlags)
+
+	return cmd
+}
+
+func newRuleCommand() *cobra.Command {
+	var format string
+	var target string
+
+	var cmd = &cobra.Command{
+		Use:   "rule [flags] <rule id>",
+		Short: "Show when a rule started failing",
+		Long: `Show per target when the rule first failed, since when it is failing and when it was last fixed.
+Errored and skipped runs do not end a failure.
+
+Expected arguments:  <rule id>
+		`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("Rule takes exactly one argument, the id of the rule (Got: '%s')", strings.Join(args, " "))
+			}
+			return checkFormat(format)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			runs, err := loadRuns(target)
+			if err != nil {
+				return err
+			}
+			trends := GetRuleTrends(runs, args[0])
+			if len(trends) == 0 && format == "text" {
+				_, err = fmt.Fprintf(os.Stdout, "No recorded runs contain the rule %s\n", args[0])
+				return err
+			}
+			return writeRuleTrends(os.Stdout, trends, format)
+		},
+	}
+
+	ruleFlags := pflag.NewFlagSet("Rule Options", pflag.ExitOnError)
+
+	ruleFlags.StringVar(&format, "format", "text", "Output format (json, text)")
+	ruleFlags.SetAnnotation("format", "group", []string{ruleFlags.Name()})
+	ruleFlags.StringVar(&target, "target", "", "Only show the rule for this target or Dockerfile")
+	ruleFlags.SetAnnotation("target", "group", []string{ruleFlags.Name()})
+
+	cmd.Flags().AddFlagSet(ruleFlags)
+
+	return cmd
+}
+
+// The latest runs, newest first
+func listRuns(runs []Run, limit int) []Run {
+	listed := []Run{}
+	for i := len(runs) - 1; i >= 0; i-- {
+		if limit > 0 && len(listed) == limit {
+			break
+		}
+		listed = append(listed, runs[i])
+	}
+	return listed
+}
+
+func writeJson(w io.Writer, value any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+func formatTime(t time.Time) string {
+	return t.Local().Format(time.DateTime)
+}
+
+func shortDigest(digest string) string {
+	if len(digest) == 0 {
+		return "-"
+	}
+	_, hash, found := strings.Cut(digest, ":")
+	if !found {
+		hash = digest
+	}
+	return hash[:min(len(hash), 12)]
+}
+
+func orDash(value string) string {
+	if len(value) == 0 {
+		return "-"
+	}
+	return value
+}
+
+func writeRunList(w io.Writer, runs []Run, format string) error {
+	if format == "json" {
+		return writeJson(w, runs)
+	}
+	for _, run := range runs {
+		_, err := fmt.Fprintf(w, "%d\t%s\t%s\truleset=%s\timage=%s\tchecked=%d\tviolations=%d\tfailing=%d\terrors=%d\n", run.Id, formatTime(run.StartedAt), run.Target(), orDash(run.RuleSetVersion), shortDigest(run.ImageDigest), run.Checked, run.Violations, run.Failing, run.Errors)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeTrends(w io.Writer, trends []TargetTrend, format string) error {
+	if format == "json" {
+		return writeJson(w, trends)
+	}
+	for _, trend := range trends {
+		if _, err := fmt.Fprintf(w, "%s (%d runs)\n", trend.Target, trend.Runs); err != nil {
+			return err
+		}
+		for _, point := range trend.Points {
+			_, err := fmt.Fprintf(w, "  %d\t%s\truleset=%s\timage=%s\tpassed=%d/%d\tviolations=%d\terrors=%d\n", point.RunId, formatTime(point.StartedAt), orDash(point.RuleSetVersion), shortDigest(point.ImageDigest), point.Passed, point.Checked, point.Violations, point.Errors)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func formatReference(reference *RunReference) string {
+	if reference == nil {
+		return "-"
+	}
+	return fmt.Sprintf("run %d (%s, ruleset=%s, image=%s)", reference.RunId, formatTime(reference.StartedAt), orDash(reference.RuleSetVersion), shortDigest(reference.ImageDigest))
+}
+
+func writeRuleTrends(w io.Writer, trends []RuleTrend, format string) error {
+	if format == "json" {
+		return writeJson(w, trends)
+	}
+	for _, trend := range trends {
+		_, err := fmt.Fprintf(w, "%s\t%s\tstatus=%s\tfailing=%d/%d runs\n  first failure: %s\n  failing since: %s\n  last fixed:    %s\n", trend.Target, trend.RuleId, trend.LastStatus, trend.FailingRuns, trend.Runs, formatReference(trend.FirstFailure), formatReference(trend.FailingSince), formatReference(trend.LastFixed))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package history
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/viper"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Name of the database file in the data directory
+const databaseName = "history.db"
+
+// Bump on breaking changes to the stored runs
+const storeVersion = 1
+
+var (
+	runsBucket    = []byte("runs")
+	metaBucket    = []byte("meta")
+	versionKey    = []byte("version")
+	ErrNoDatabase = errors.New("No run history found, runs are recorded by validate")
+)
+
+// A recorded validate run
+type Run struct {
+	// Increasing in the order the runs were recorded
+	Id uint64 `json:"id"`
+	// Path of the Dockerfile within the repository, the absolute path for local Dockerfiles outside of a git repository
+	Dockerfile string `json:"dockerfile"`
+	// Url of remote repositories, the root directory of the git repository of local Dockerfiles
+	Repository string `json:"repository,omitempty"`
+	// sha256 of the validated Dockerfile
+	DockerfileHash string        `json:"dockerfile_hash"`
+	Image          string        `json:"image,omitempty"`
+	ImageDigest    string        `json:"image_digest,omitempty"`
+	RuleSet        string        `json:"ruleset"`
+	RuleSetName    string        `json:"ruleset_name,omitempty"`
+	RuleSetVersion string        `json:"ruleset_version,omitempty"`
+	ToolVersion    string        `json:"tool_version"`
+	StartedAt      time.Time     `json:"started_at"`
+	Duration       time.Duration `json:"duration"`
+	Checked        int           `json:"checked"`
+	Violations     int           `json:"violations"`
+	Failing        int           `json:"failing"`
+	Errors         int           `json:"errors"`
+	Rules          []RuleOutcome `json:"rules"`
+}
+
+type RuleOutcome struct {
+	Id string `json:"id"`
+	// One of passed, violated, excepted, errored and skipped
+	Status string `json:"status"`
+	// Violations that count, i.e. that are not suppressed, waived or baselined
+	Violations int `json:"violations"`
+}
+
+// Runs of the same Dockerfile (and repository) are grouped into a target
+func (r Run) Target() string {
+	return getTarget(r.Repository, r.Dockerfile)
+}
+
+func getTarget(repository, dockerfile string) string {
+	if len(repository) == 0 {
+		return dockerfile
+	}
+	return fmt.Sprintf("%s!%s", repository, dockerfile)
+}
+
+// Repository and Dockerfile of a local Dockerfile
+// Dockerfiles in a git repository are identified by the root of the repository and their path within, others by their absolute path
+// The history is shared by all projects of the user, so the path as passed to validate is not unique
+func GetLocalTarget(path string) (repository string, dockerfile string, err error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", "", err
+	}
+	for dir := filepath.Dir(absPath); ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			relPath, err := filepath.Rel(dir, absPath)
+			if err != nil {
+				return "", "", err
+			}
+			return dir, filepath.ToSlash(relPath), nil
+		}
+		if filepath.Dir(dir) == dir {
+			return "", absPath, nil
+		}
+	}
+}
+
+// Outcome of the rule in the run, nil if the rule was not part of the run
+func (r Run) GetRule(ruleId string) *RuleOutcome {
+	for i := range r.Rules {
+		if r.Rules[i].Id == ruleId {
+			return &r.Rules[i]
+		}
+	}
+	return nil
+}
+
+// Configured data directory, $XDG_DATA_HOME/whale-watcher or ~/.local/share/whale-watcher otherwise
+func GetDataDir() (string, error) {
+	if dataDir := viper.GetString("data_dir"); len(dataDir) > 0 {
+		return dataDir, nil
+	}
+	if dataHome := os.Getenv("XDG_DATA_HOME"); len(dataHome) > 0 {
+		return filepath.Join(dataHome, "whale-watcher"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("Could not determine the data directory, set data_dir: %w", err)
+	}
+	return filepath.Join(home, ".local", "share", "whale-watcher"), nil
+}
+
+func getDatabasePath() (string, error) {
+	dataDir, err := GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, databaseName), nil
+}
+
+// Concurrent runs wait for each other, a stale lock must not block validate forever
+func openDatabase(path string, readOnly bool) (*bolt.DB, error) {
+	return bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second, ReadOnly: readOnly})
+}
+
+// Store the run in the history of the configured data directory, the id of the run is assigned on insert
+func Record(run Run) (uint64, error) {
+	path, err := getDatabasePath()
+	if err != nil {
+		return 0, err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return 0, err
+	}
+	db, err := openDatabase(path, false)
+	if err != nil {
+		return 0, fmt.Errorf("Could not open run history %s: %w", path, err)
+	}
+	defer db.Close()
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucketIfNotExists(metaBucket)
+		if err != nil {
+			return err
+		}
+		if err = checkVersion(meta, path); err != nil {
+			return err
+		}
+		if meta.Get(versionKey) == nil {
+			if err = meta.Put(versionKey, itob(storeVersion)); err != nil {
+				return err
+			}
+		}
+		runs, err := tx.CreateBucketIfNotExists(runsBucket)
+		if err != nil {
+			return err
+		}
+		run.Id, err = runs.NextSequence()
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(run)
+		if err != nil {
+			return err
+		}
+		return runs.Put(itob(run.Id), data)
+	})
+	return run.Id, err
+}
+
+// Every recorded run, oldest first
+func Load() ([]Run, error) {
+	path, err := getDatabasePath()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoDatabase
+	}
+	db, err := openDatabase(path, true)
+	if err != nil {
+		return nil, fmt.Errorf("Could not open run history %s: %w", path, err)
+	}
+	defer db.Close()
+
+	runs := []Run{}
+	err = db.View(func(tx *bolt.Tx) error {
+		if meta := tx.Bucket(metaBucket); meta != nil {
+			if err := checkVersion(meta, path); err != nil {
+				return err
+			}
+		}
+		bucket := tx.Bucket(runsBucket)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(_, value []byte) error {
+			run := Run{}
+			if err := json.Unmarshal(value, &run); err != nil {
+				return fmt.Errorf("Invalid run in history %s: %w", path, err)
+			}
+			runs = append(runs, run)
+			return nil
+		})
+	})
+	return runs, err
+}
+
+func checkVersion(meta *bolt.Bucket, path string) error {
+	raw := meta.Get(versionKey)
+	if raw == nil {
+		return nil
+	}
+	if version := binary.BigEndian.Uint64(raw); version != storeVersion {
+		return fmt.Errorf("Unsupported run history version %d in %s (Supported: %d)", version, path, storeVersion)
+	}
+	return nil
+}
+
+// Big endian keeps the keys in insertion order
+func itob(value uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, value)
+	return b
+}
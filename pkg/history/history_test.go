@@ -0,0 +1,208 @@
+package history_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/history"
+	violationTypes "github.com/coffeemakingtoaster/whale-watcher/pkg/validator/violations"
+	"github.com/spf13/viper"
+)
+
+var start = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func newRun(day int, repository, dockerfile string, outcomes ...history.RuleOutcome) history.Run {
+	run := history.Run{
+		Dockerfile:     dockerfile,
+		Repository:     repository,
+		DockerfileHash: "abc",
+		RuleSetVersion: "1.0.0",
+		StartedAt:      start.AddDate(0, 0, day),
+		Checked:        len(outcomes),
+		Rules:          outcomes,
+	}
+	for _, outcome := range outcomes {
+		run.Violations += outcome.Violations
+		if outcome.Status == violationTypes.RuleStatusErrored {
+			run.Errors++
+		}
+	}
+	return run
+}
+
+func outcome(id, status string) history.RuleOutcome {
+	res := history.RuleOutcome{Id: id, Status: status}
+	if status == violationTypes.RuleStatusViolated {
+		res.Violations = 1
+	}
+	return res
+}
+
+func TestRecordAndLoad(t *testing.T) {
+	viper.Set("data_dir", t.TempDir())
+	defer viper.Set("data_dir", "")
+
+	if _, err := history.Load(); !errors.Is(err, history.ErrNoDatabase) {
+		t.Fatalf("Expected ErrNoDatabase for an empty data directory, got %v", err)
+	}
+
+	for i, run := range []history.Run{
+		newRun(0, "", "Dockerfile", outcome("a", violationTypes.RuleStatusViolated)),
+		newRun(1, "https://example.com/repo.git", "Dockerfile", outcome("a", violationTypes.RuleStatusPassed)),
+	} {
+		id, err := history.Record(run)
+		if err != nil {
+			t.Fatalf("Unexpected error recording run: %v", err)
+		}
+		if id != uint64(i+1) {
+			t.Errorf("Expected run id %d, got %d", i+1, id)
+		}
+	}
+
+	runs, err := history.Load()
+	if err != nil {
+		t.Fatalf("Unexpected error loading runs: %v", err)
+	}
+	if len(runs) != 2 {
+		t.Fatalf("Expected 2 runs, got %d", len(runs))
+	}
+	if runs[0].Id != 1 || runs[1].Id != 2 {
+		t.Errorf("Expected runs ordered oldest first, got ids %d and %d", runs[0].Id, runs[1].Id)
+	}
+	if !runs[0].StartedAt.Equal(start) {
+		t.Errorf("Expected start %s, got %s", start, runs[0].StartedAt)
+	}
+	if runs[1].Target() != "https://example.com/repo.git!Dockerfile" {
+		t.Errorf("Unexpected target %s", runs[1].Target())
+	}
+	if rule := runs[0].GetRule("a"); rule == nil || rule.Violations != 1 {
+		t.Errorf("Expected rule a with one violation, got %+v", rule)
+	}
+}
+
+func TestGetLocalTarget(t *testing.T) {
+	dir := t.TempDir()
+	repository := filepath.Join(dir, "project")
+	if err := os.MkdirAll(filepath.Join(repository, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][2]string{
+		filepath.Join(repository, "Dockerfile"):                 {repository, "Dockerfile"},
+		filepath.Join(repository, "docker", "Dockerfile"):       {repository, "docker/Dockerfile"},
+		filepath.Join(repository, "docker", "..", "Dockerfile"): {repository, "Dockerfile"},
+		filepath.Join(dir, "other", "Dockerfile"):               {"", filepath.Join(dir, "other", "Dockerfile")},
+	}
+	for path, target := range expected {
+		repository, dockerfile, err := history.GetLocalTarget(path)
+		if err != nil {
+			t.Fatalf("Unexpected error resolving %s: %v", path, err)
+		}
+		if repository != target[0] || dockerfile != target[1] {
+			t.Errorf("Target mismatch for %s: Expected %v Got [%s %s]", path, target, repository, dockerfile)
+		}
+	}
+}
+
+func TestFilterByTarget(t *testing.T) {
+	dir := t.TempDir()
+	runs := []history.Run{
+		newRun(0, filepath.Join(dir, "a"), "Dockerfile"),
+		newRun(1, filepath.Join(dir, "b"), "Dockerfile"),
+		newRun(2, "https://example.com/repo.git", "Dockerfile"),
+		newRun(3, "", filepath.Join(dir, "c", "Dockerfile")),
+	}
+	for _, project := range []string{"a", "b"} {
+		if err := os.MkdirAll(filepath.Join(dir, project, ".git"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if res := history.FilterByTarget(runs, ""); len(res) != 4 {
+		t.Errorf("Expected all runs without a target, got %d", len(res))
+	}
+	// Dockerfiles of different projects with the same name are different targets
+	if res := history.FilterByTarget(runs, filepath.Join(dir, "a", "Dockerfile")); len(res) != 1 || res[0].Repository != filepath.Join(dir, "a") {
+		t.Errorf("Expected only the run of project a, got %v", res)
+	}
+	if res := history.FilterByTarget(runs, filepath.Join(dir, "c", "..", "c", "Dockerfile")); len(res) != 1 {
+		t.Errorf("Expected the run outside a repository, got %d", len(res))
+	}
+	if res := history.FilterByTarget(runs, "https://example.com/repo.git!Dockerfile"); len(res) != 1 {
+		t.Errorf("Expected only the remote run, got %d", len(res))
+	}
+	if res := history.FilterByTarget(runs, "Dockerfile"); len(res) != 0 {
+		t.Errorf("Expected no run for a Dockerfile of another directory, got %d", len(res))
+	}
+}
+
+func TestGetTrends(t *testing.T) {
+	runs := []history.Run{
+		newRun(0, "", "Dockerfile", outcome("a", violationTypes.RuleStatusViolated), outcome("b", violationTypes.RuleStatusPassed)),
+		newRun(1, "https://example.com/repo.git", "Dockerfile", outcome("a", violationTypes.RuleStatusPassed)),
+		newRun(2, "", "Dockerfile", outcome("a", violationTypes.RuleStatusPassed), outcome("b", violationTypes.RuleStatusPassed)),
+		newRun(3, "", "Dockerfile", outcome("a", violationTypes.RuleStatusPassed), outcome("b", violationTypes.RuleStatusErrored)),
+	}
+	for i := range runs {
+		runs[i].Id = uint64(i + 1)
+	}
+
+	trends := history.GetTrends(runs, 2)
+	if len(trends) != 2 {
+		t.Fatalf("Expected 2 targets, got %d", len(trends))
+	}
+	local := trends[0]
+	if local.Target != "Dockerfile" || local.Runs != 3 {
+		t.Errorf("Expected 3 runs of the local Dockerfile, got %d of %s", local.Runs, local.Target)
+	}
+	if len(local.Points) != 2 {
+		t.Fatalf("Expected the limit to keep 2 points, got %d", len(local.Points))
+	}
+	if local.Points[0].RunId != 3 || local.Points[1].RunId != 4 {
+		t.Errorf("Expected the latest runs, got %d and %d", local.Points[0].RunId, local.Points[1].RunId)
+	}
+	if local.Points[0].Passed != 2 || local.Points[1].Passed != 1 || local.Points[1].Errors != 1 {
+		t.Errorf("Unexpected points %+v", local.Points)
+	}
+	if trends[1].Runs != 1 {
+		t.Errorf("Expected the remote run to form its own target, got %d runs", trends[1].Runs)
+	}
+}
+
+func TestGetRuleTrends(t *testing.T) {
+	runs := []history.Run{
+		newRun(0, "", "Dockerfile", outcome("a", violationTypes.RuleStatusPassed)),
+		newRun(1, "", "Dockerfile", outcome("a", violationTypes.RuleStatusViolated)),
+		newRun(2, "", "Dockerfile", outcome("a", violationTypes.RuleStatusExcepted)),
+		newRun(3, "", "Dockerfile", outcome("a", violationTypes.RuleStatusViolated)),
+		newRun(4, "", "Dockerfile", outcome("a", violationTypes.RuleStatusErrored)),
+		newRun(5, "", "Dockerfile", outcome("a", violationTypes.RuleStatusViolated)),
+		newRun(6, "", "other/Dockerfile", outcome("b", violationTypes.RuleStatusViolated)),
+	}
+	for i := range runs {
+		runs[i].Id = uint64(i + 1)
+	}
+
+	trends := history.GetRuleTrends(runs, "a")
+	if len(trends) != 1 {
+		t.Fatalf("Expected only the target that ran the rule, got %d", len(trends))
+	}
+	trend := trends[0]
+	if trend.Runs != 6 || trend.FailingRuns != 3 {
+		t.Errorf("Expected 3 of 6 runs failing, got %d of %d", trend.FailingRuns, trend.Runs)
+	}
+	if trend.FirstFailure == nil || trend.FirstFailure.RunId != 2 {
+		t.Errorf("Expected the first failure in run 2, got %+v", trend.FirstFailure)
+	}
+	if trend.LastFixed == nil || trend.LastFixed.RunId != 3 {
+		t.Errorf("Expected the failure to be fixed in run 3, got %+v", trend.LastFixed)
+	}
+	// The errored run does not end the failure that started in run 4
+	if trend.FailingSince == nil || trend.FailingSince.RunId != 4 {
+		t.Errorf("Expected the rule to be failing since run 4, got %+v", trend.FailingSince)
+	}
+	if trend.LastStatus != violationTypes.RuleStatusViolated {
+		t.Errorf("Expected last status violated, got %s", trend.LastStatus)
+	}
+}
@@ -0,0 +1,164 @@
+package history
+
+import (
+	"slices"
+	"time"
+
+	violationTypes "github.com/coffeemakingtoaster/whale-watcher/pkg/validator/violations"
+)
+
+// Runs of the target, all runs if target is empty
+// The target is either the target of the run or a path to a local Dockerfile, which is resolved the same way validate does it
+func FilterByTarget(runs []Run, target string) []Run {
+	if len(target) == 0 {
+		return runs
+	}
+	targets := []string{target}
+	if repository, dockerfile, err := GetLocalTarget(target); err == nil {
+		targets = append(targets, getTarget(repository, dockerfile))
+	}
+	res := []Run{}
+	for _, run := range runs {
+		if slices.Contains(targets, run.Target()) {
+			res = append(res, run)
+		}
+	}
+	return res
+}
+
+// Targets in the order they were first validated
+func GetTargets(runs []Run) []string {
+	targets := []string{}
+	for _, run := range runs {
+		if !slices.Contains(targets, run.Target()) {
+			targets = append(targets, run.Target())
+		}
+	}
+	return targets
+}
+
+func getRunsOfTarget(runs []Run, target string) []Run {
+	res := []Run{}
+	for _, run := range runs {
+		if run.Target() == target {
+			res = append(res, run)
+		}
+	}
+	return res
+}
+
+// Development of a target over its runs
+type TargetTrend struct {
+	Target string `json:"target"`
+	// Number of recorded runs, Points only contains the latest ones
+	Runs   int          `json:"runs"`
+	Points []TrendPoint `json:"points"`
+}
+
+type TrendPoint struct {
+	RunId          uint64    `json:"run_id"`
+	StartedAt      time.Time `json:"started_at"`
+	RuleSetVersion string    `json:"ruleset_version,omitempty"`
+	ImageDigest    string    `json:"image_digest,omitempty"`
+	Checked        int       `json:"checked"`
+	Passed         int       `json:"passed"`
+	Violations     int       `json:"violations"`
+	Failing        int       `json:"failing"`
+	Errors         int       `json:"errors"`
+}
+
+// Trend of every target with at most limit points each, all points if limit is 0
+func GetTrends(runs []Run, limit int) []TargetTrend {
+	trends := []TargetTrend{}
+	for _, target := range GetTargets(runs) {
+		targetRuns := getRunsOfTarget(runs, target)
+		trend := TargetTrend{Target: target, Runs: len(targetRuns), Points: []TrendPoint{}}
+		if limit > 0 && len(targetRuns) > limit {
+			targetRuns = targetRuns[len(targetRuns)-limit:]
+		}
+		for _, run := range targetRuns {
+			point := TrendPoint{
+				RunId:          run.Id,
+				StartedAt:      run.StartedAt,
+				RuleSetVersion: run.RuleSetVersion,
+				ImageDigest:    run.ImageDigest,
+				Checked:        run.Checked,
+				Violations:     run.Violations,
+				Failing:        run.Failing,
+				Errors:         run.Errors,
+			}
+			for _, rule := range run.Rules {
+				if rule.Status == violationTypes.RuleStatusPassed {
+					point.Passed++
+				}
+			}
+			trend.Points = append(trend.Points, point)
+		}
+		trends = append(trends, trend)
+	}
+	return trends
+}
+
+// When a rule failed for a target
+// A rule fails if it has violations that count; errored and skipped runs or runs without the rule do not end a failure
+type RuleTrend struct {
+	RuleId string `json:"rule"`
+	Target string `json:"target"`
+	// Runs that contained the rule
+	Runs        int `json:"runs"`
+	FailingRuns int `json:"failing_runs"`
+	// First run the rule failed in, nil if it never failed
+	FirstFailure *RunReference `json:"first_failure"`
+	// Run the ongoing failure started in, nil if the rule is not failing
+	FailingSince *RunReference `json:"failing_since"`
+	// Run the last failure was fixed in, nil if the rule never recovered from a failure
+	LastFixed  *RunReference `json:"last_fixed"`
+	LastStatus string        `json:"last_status"`
+}
+
+type RunReference struct {
+	RunId          uint64    `json:"run_id"`
+	StartedAt      time.Time `json:"started_at"`
+	RuleSetVersion string    `json:"ruleset_version,omitempty"`
+	ImageDigest    string    `json:"image_digest,omitempty"`
+	DockerfileHash string    `json:"dockerfile_hash"`
+}
+
+func toReference(run Run) *RunReference {
+	return &RunReference{RunId: run.Id, StartedAt: run.StartedAt, RuleSetVersion: run.RuleSetVersion, ImageDigest: run.ImageDigest, DockerfileHash: run.DockerfileHash}
+}
+
+// Failure history of the rule for every target it was run for
+func GetRuleTrends(runs []Run, ruleId string) []RuleTrend {
+	trends := []RuleTrend{}
+	for _, target := range GetTargets(runs) {
+		trend := RuleTrend{RuleId: ruleId, Target: target}
+		for _, run := range getRunsOfTarget(runs, target) {
+			rule := run.GetRule(ruleId)
+			if rule == nil {
+				continue
+			}
+			trend.Runs++
+			trend.LastStatus = rule.Status
+			switch rule.Status {
+			case violationTypes.RuleStatusViolated:
+				trend.FailingRuns++
+				if trend.FirstFailure == nil {
+					trend.FirstFailure = toReference(run)
+				}
+				if trend.FailingSince == nil {
+					trend.FailingSince = toReference(run)
+				}
+			case violationTypes.RuleStatusPassed, violationTypes.RuleStatusExcepted:
+				if trend.FailingSince != nil {
+					trend.LastFixed = toReference(run)
+				}
+				trend.FailingSince = nil
+			}
+		}
+		if trend.Runs > 0 {
+			trends = append(trends, trend)
+		}
+	}
+	return trends
+}
@@ -0,0 +1,251 @@
+package rules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func NewCommand() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "rules",
+		Short: "Manage and check policy sets",
+		Long:  `Tooling for authoring and maintaining policy sets.`,
+	}
+
+	cmd.AddCommand(newLockCommand())
+	cmd.AddCommand(newLintCommand())
+	cmd.AddCommand(newTestCommand())
+
+	return cmd
+}
+
+func newLockCommand() *cobra.Command {
+	var lockfilePath string
+
+	var cmd = &cobra.Command{
+		Use:   "lock [flags] <policyset>",
+		Short: "Pin all remote includes of a policy set",
+		Long: `Resolve every remote policy set that is (transitively) included to a commit and content digest and write them to a lockfile.
+By default the lockfile is placed beside the policy set (policies.yaml -> policies.lock.yaml) and is picked up automatically by validate.
+Lockfiles written to another location (required for remote policy sets) are used by passing them to validate using --lockfile.
+
+Expected arguments:  <policy set location>
+		`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("Lock only takes exactly one argument, the path for a ruleset (Got: '%s')", strings.Join(args, " "))
+			}
+			if isRepositoryLocation(args[0]) && lockfilePath == "" {
+				return errors.New("Policy set is not a local file, the lockfile location has to be set using --file")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			lockfile, err := LockRuleset(args[0])
+			if err != nil {
+				return err
+			}
+			if lockfilePath == "" {
+				lockfilePath = GetLockfilePath(args[0])
+			}
+			if err = lockfile.Write(lockfilePath); err != nil {
+				return err
+			}
+			log.Info().Str("lockfile", lockfilePath).Int("locked", len(lockfile.Rulesets)).Msg("Lockfile written")
+			return nil
+		},
+	}
+
+	lockFlags := pflag.NewFlagSet("Lock Options", pflag.ExitOnError)
+
+	lockFlags.StringVarP(&lockfilePath, "file", "f", "", "Set the file to write the lockfile to (default: beside the policy set)")
+	lockFlags.SetAnnotation("file", "group", []string{lockFlags.Name()})
+
+	cmd.Flags().AddFlagSet(lockFlags)
+
+	return cmd
+}
+
+func newLintCommand() *cobra.Command {
+	var format string
+	var outputPath string
+
+	var cmd = &cobra.Command{
+		Use:   "lint [flags] <policyset>",
+		Short: "Statically check a policy set",
+		Long: `Check the policy set and all its includes for duplicate ids, python syntax errors, utils that are not available for the rule target and fix instructions that never call fix_util.finish().
+Fails if any finding has the level error.
+
+Expected arguments:  <policy set location>
+		`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("Lint only takes exactly one argument, the path for a ruleset (Got: '%s')", strings.Join(args, " "))
+			}
+			if format != "json" && format != "text" {
+				return fmt.Errorf("Unsupported format: %s (supported: 'json', 'text')", format)
+			}
+			// Findings written to stdout have to stay parseable, so the console output moves to stderr
+			if format == "json" && outputPath == "" {
+				log.Logger = log.Logger.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			result, err := LintRuleset(args[0])
+			if err != nil {
+				return err
+			}
+
+			var output io.Writer = os.Stdout
+			if outputPath != "" {
+				f, err := os.Create(outputPath)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				output = f
+			}
+
+			if err = writeLintResult(output, result, format); err != nil {
+				return err
+			}
+			if result.Errors > 0 {
+				return fmt.Errorf("Lint found %d errors and %d warnings", result.Errors, result.Warnings)
+			}
+			return nil
+		},
+	}
+
+	lintFlags := pflag.NewFlagSet("Lint Options", pflag.ExitOnError)
+
+	lintFlags.StringVar(&format, "format", "json", "Output format (json, text)")
+	lintFlags.SetAnnotation("format", "group", []string{lintFlags.Name()})
+	lintFlags.StringVarP(&outputPath, "output", "o", "", "Write the findings to this file instead of stdout")
+	lintFlags.SetAnnotation("output", "group", []string{lintFlags.Name()})
+
+	cmd.Flags().AddFlagSet(lintFlags)
+
+	return cmd
+}
+
+func writeLintResult(w io.Writer, result LintResult, format string) error {
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+	for _, finding := range result.Findings {
+		_, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s: %s\n", finding.Level, finding.Check, finding.RuleId, finding.Source, finding.Message)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d errors, %d warnings\n", result.Errors, result.Warnings)
+	return err
+}
+
+func newTestCommand() *cobra.Command {
+	var format string
+	var outputPath string
+	var only []string
+	var skip []string
+
+	var cmd = &cobra.Command{
+		Use:   "test [flags] <policyset>",
+		Short: "Run the test cases of a policy set",
+		Long: `Run the test cases declared in the tests section of the rules against their inline Dockerfiles. No image is needed, so only rules with target command can be tested.
+Fails if any test fails or cannot be run.
+
+Expected arguments:  <policy set location>
+		`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("Test only takes exactly one argument, the path for a ruleset (Got: '%s')", strings.Join(args, " "))
+			}
+			if format != "json" && format != "text" {
+				return fmt.Errorf("Unsupported format: %s (supported: 'json', 'text')", format)
+			}
+			// Results written to stdout have to stay parseable, so the console output moves to stderr
+			if format == "json" && outputPath == "" {
+				log.Logger = log.Logger.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ruleSet, err := LoadRuleset(args[0])
+			if err != nil {
+				return err
+			}
+			defer ruleSet.Close()
+			if err = ruleSet.Filter(only, skip); err != nil {
+				return err
+			}
+
+			report := ruleSet.RunTests()
+
+			var output io.Writer = os.Stdout
+			if outputPath != "" {
+				f, err := os.Create(outputPath)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				output = f
+			}
+
+			if err = writeTestReport(output, report, format); err != nil {
+				return err
+			}
+			if report.Failed > 0 || report.Errors > 0 {
+				return fmt.Errorf("%d tests failed and %d could not be run", report.Failed, report.Errors)
+			}
+			return nil
+		},
+	}
+
+	testFlags := pflag.NewFlagSet("Test Options", pflag.ExitOnError)
+
+	testFlags.StringVar(&format, "format", "json", "Output format (json, text)")
+	testFlags.SetAnnotation("format", "group", []string{testFlags.Name()})
+	testFlags.StringVarP(&outputPath, "output", "o", "", "Write the results to this file instead of stdout")
+	testFlags.SetAnnotation("output", "group", []string{testFlags.Name()})
+	testFlags.StringArrayVar(&only, "only", []string{}, "Only test rules matching the selector (rule id, glob or tag expression like 'tag:security && !tag:slow'). Can be repeated")
+	testFlags.SetAnnotation("only", "group", []string{testFlags.Name()})
+	testFlags.StringArrayVar(&skip, "skip", []string{}, "Skip rules matching the selector (rule id, glob or tag expression). Can be repeated")
+	testFlags.SetAnnotation("skip", "group", []string{testFlags.Name()})
+
+	cmd.Flags().AddFlagSet(testFlags)
+
+	return cmd
+}
+
+func writeTestReport(w io.Writer, report RuleTestReport, format string) error {
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	for _, result := range report.Results {
+		_, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", result.Status, result.RuleId, result.Name, result.Source)
+		if err != nil {
+			return err
+		}
+		if len(result.Message) > 0 {
+			if _, err = fmt.Fprintf(w, "\t%s\n", strings.ReplaceAll(result.Message, "\n", "\n\t")); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d passed, %d failed, %d errors\n", report.Passed, report.Failed, report.Errors)
+	return err
+}
@@ -0,0 +1,95 @@
+package rules_test
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/rules"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+var unassertedRuleset = `
+name: unasserted
+rules:
+  - category: Negative
+    instruction: |
+        print("never fails")
+    description: Does not assert anything
+    id: unasserted
+    target: fs
+`
+
+var unassertedTestedRuleset = unassertedRuleset + `    tests:
+      - dockerfile: FROM ubuntu
+        expect: pass
+`
+
+// Run the rules command with the console output set up like main, i.e. written to stdout
+func executeRulesCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	logger := log.Logger
+	os.Stdout = writer
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stdout})
+	defer func() {
+		os.Stdout = stdout
+		log.Logger = logger
+	}()
+
+	output := make(chan string)
+	go func() {
+		content, _ := io.ReadAll(reader)
+		output <- string(content)
+	}()
+
+	cmd := rules.NewCommand()
+	cmd.SetArgs(args)
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	err = cmd.Execute()
+	writer.Close()
+	return <-output, err
+}
+
+func TestLintCommandJsonOutput(t *testing.T) {
+	dir := t.TempDir()
+	writeRulesetFiles(t, dir, map[string]string{"unasserted.yaml": unassertedRuleset})
+
+	output, err := executeRulesCommand(t, "lint", filepath.Join(dir, "unasserted.yaml"))
+	if err != nil {
+		t.Fatalf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+	var result rules.LintResult
+	if err = json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("Stdout is not valid json: %s\n%s", err.Error(), output)
+	}
+	if result.Warnings != 1 {
+		t.Errorf("Warning count mismatch: Expected 1 Got %d", result.Warnings)
+	}
+}
+
+func TestTestCommandJsonOutput(t *testing.T) {
+	dir := t.TempDir()
+	writeRulesetFiles(t, dir, map[string]string{"unasserted.yaml": unassertedTestedRuleset})
+
+	// Tests of fs rules cannot be run, which fails the command but is still reported
+	output, err := executeRulesCommand(t, "test", filepath.Join(dir, "unasserted.yaml"))
+	if err == nil {
+		t.Errorf("Error mismatch: Expected error Got nil")
+	}
+	var report rules.RuleTestReport
+	if err = json.Unmarshal([]byte(output), &report); err != nil {
+		t.Fatalf("Stdout is not valid json: %s\n%s", err.Error(), output)
+	}
+	if report.Errors != 1 || len(report.Results) != 1 || report.Results[0].RuleId != "unasserted" {
+		t.Errorf("Report mismatch: Expected 1 error for unasserted Got %+v", report)
+	}
+}
@@ -0,0 +1,229 @@
+package rules
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Parsed form of a rule selector as passed via --only/--skip
+// Supported are rule ids, globs on rule ids (e.g. apt_*) and tags (tag:<name>)
+// combined using &&, ||, ! and parentheses
+type RuleSelector interface {
+	Matches(rule *Rule) bool
+}
+
+type idSelector struct {
+	pattern string
+}
+
+func (s idSelector) Matches(rule *Rule) bool {
+	if s.pattern == rule.Id {
+		return true
+	}
+	matched, err := path.Match(s.pattern, rule.Id)
+	return err == nil && matched
+}
+
+type tagSelector struct {
+	tag string
+}
+
+func (s tagSelector) Matches(rule *Rule) bool {
+	return slices.ContainsFunc(rule.Tags, func(tag string) bool {
+		return strings.EqualFold(tag, s.tag)
+	})
+}
+
+type notSelector struct {
+	inner RuleSelector
+}
+
+func (s notSelector) Matches(rule *Rule) bool { return !s.inner.Matches(rule) }
+
+type andSelector struct {
+	left, right RuleSelector
+}
+
+func (s andSelector) Matches(rule *Rule) bool { return s.left.Matches(rule) && s.right.Matches(rule) }
+
+type orSelector struct {
+	left, right RuleSelector
+}
+
+func (s orSelector) Matches(rule *Rule) bool { return s.left.Matches(rule) || s.right.Matches(rule) }
+
+// Parse a selector expression such as `tag:security && !tag:slow`
+func ParseRuleSelector(expression string) (RuleSelector, error) {
+	tokens, err := tokenizeSelector(expression)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("Empty rule selector")
+	}
+	parser := selectorParser{tokens: tokens}
+	selector, err := parser.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("Invalid rule selector '%s': %s", expression, err.Error())
+	}
+	if parser.pos != len(parser.tokens) {
+		return nil, fmt.Errorf("Invalid rule selector '%s': unexpected '%s'", expression, parser.tokens[parser.pos])
+	}
+	return selector, nil
+}
+
+// Rule ids may contain spaces, so everything up to the next operator is a single term
+func tokenizeSelector(expression string) ([]string, error) {
+	tokens := []string{}
+	term := strings.Builder{}
+	flushTerm := func() {
+		if trimmed := strings.TrimSpace(term.String()); len(trimmed) > 0 {
+			tokens = append(tokens, trimmed)
+		}
+		term.Reset()
+	}
+	for i := 0; i < len(expression); i++ {
+		switch {
+		case strings.HasPrefix(expression[i:], "&&"), strings.HasPrefix(expression[i:], "||"):
+			flushTerm()
+			tokens = append(tokens, expression[i:i+2])
+			i++
+		case expression[i] == '(' || expression[i] == ')':
+			flushTerm()
+			tokens = append(tokens, string(expression[i]))
+		case expression[i] == '!' && len(strings.TrimSpace(term.String())) == 0:
+			tokens = append(tokens, "!")
+		case expression[i] == '&' || expression[i] == '|':
+			return nil, fmt.Errorf("Invalid rule selector '%s': single '%c' is not an operator", expression, expression[i])
+		default:
+			term.WriteByte(expression[i])
+		}
+	}
+	flushTerm()
+	return tokens, nil
+}
+
+type selectorParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *selectorParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *selectorParser) parseOr() (RuleSelector, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "||" {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orSelector{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *selectorParser) parseAnd() (RuleSelector, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "&&" {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andSelector{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *selectorParser) parseUnary() (RuleSelector, error) {
+	token := p.peek()
+	switch token {
+	case "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case "!":
+		p.pos++
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notSelector{inner: inner}, nil
+	case "(":
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing ')'")
+		}
+		p.pos++
+		return inner, nil
+	case ")", "&&", "||":
+		return nil, fmt.Errorf("unexpected '%s'", token)
+	}
+	p.pos++
+	if tag, ok := strings.CutPrefix(token, "tag:"); ok {
+		if len(tag) == 0 {
+			return nil, fmt.Errorf("empty tag")
+		}
+		return tagSelector{tag: tag}, nil
+	}
+	if _, err := path.Match(token, ""); err != nil {
+		return nil, fmt.Errorf("invalid glob '%s'", token)
+	}
+	return idSelector{pattern: token}, nil
+}
+
+// Reduce the ruleset to the rules matching any of the only selectors (all rules if empty)
+// and none of the skip selectors
+func (rs *RuleSet) Filter(only, skip []string) error {
+	if len(only) == 0 && len(skip) == 0 {
+		return nil
+	}
+	onlySelectors, err := parseRuleSelectors(only)
+	if err != nil {
+		return err
+	}
+	skipSelectors, err := parseRuleSelectors(skip)
+	if err != nil {
+		return err
+	}
+	matchesAny := func(selectors []RuleSelector, rule *Rule) bool {
+		return slices.ContainsFunc(selectors, func(s RuleSelector) bool { return s.Matches(rule) })
+	}
+	rs.Rules = slices.DeleteFunc(rs.Rules, func(rule *Rule) bool {
+		if len(onlySelectors) > 0 && !matchesAny(onlySelectors, rule) {
+			return true
+		}
+		return matchesAny(skipSelectors, rule)
+	})
+	rs.updateIdList()
+	rs.updateTargetList()
+	return nil
+}
+
+func parseRuleSelectors(expressions []string) ([]RuleSelector, error) {
+	selectors := make([]RuleSelector, 0, len(expressions))
+	for _, expression := range expressions {
+		selector, err := ParseRuleSelector(expression)
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, selector)
+	}
+	return selectors, nil
+}
@@ -0,0 +1,74 @@
+package rules_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/rules"
+)
+
+func getFilterTestSet() rules.RuleSet {
+	return rules.RuleSet{
+		Name: "filter",
+		Rules: []*rules.Rule{
+			{Id: "apt_sources_empty", Target: "fs", Tags: []string{"security", "apt"}},
+			{Id: "apt_cache_clean", Target: "fs", Tags: []string{"size", "apt"}},
+			{Id: "user_not_root", Target: "os", Tags: []string{"security", "slow"}},
+			{Id: "stage name", Target: "command"},
+		},
+	}
+}
+
+func getIds(ruleSet rules.RuleSet) []string {
+	ids := []string{}
+	for _, rule := range ruleSet.Rules {
+		ids = append(ids, rule.Id)
+	}
+	return ids
+}
+
+func TestFilterRuleset(t *testing.T) {
+	expected := []struct {
+		only     []string
+		skip     []string
+		expected []string
+	}{
+		{nil, nil, []string{"apt_sources_empty", "apt_cache_clean", "user_not_root", "stage name"}},
+		{[]string{"stage name"}, nil, []string{"stage name"}},
+		{[]string{"apt_*"}, nil, []string{"apt_sources_empty", "apt_cache_clean"}},
+		{[]string{"tag:security && !tag:slow"}, nil, []string{"apt_sources_empty"}},
+		{[]string{"tag:SECURITY || stage name"}, nil, []string{"apt_sources_empty", "user_not_root", "stage name"}},
+		{[]string{"!(tag:apt || tag:slow)"}, nil, []string{"stage name"}},
+		{[]string{"tag:apt", "user_not_root"}, []string{"apt_cache_clean"}, []string{"apt_sources_empty", "user_not_root"}},
+		{nil, []string{"tag:slow"}, []string{"apt_sources_empty", "apt_cache_clean", "stage name"}},
+	}
+	for _, testCase := range expected {
+		ruleSet := getFilterTestSet()
+		err := ruleSet.Filter(testCase.only, testCase.skip)
+		if err != nil {
+			t.Errorf("Error mismatch: Expected nil Got '%s'", err.Error())
+			continue
+		}
+		if actual := getIds(ruleSet); !reflect.DeepEqual(actual, testCase.expected) {
+			t.Errorf("Filter mismatch for only %v skip %v: Expected %v Got %v", testCase.only, testCase.skip, testCase.expected, actual)
+		}
+	}
+}
+
+func TestFilterUpdatesHighestTarget(t *testing.T) {
+	ruleSet := getFilterTestSet()
+	if err := ruleSet.Filter(nil, []string{"tag:slow"}); err != nil {
+		t.Errorf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+	if target := ruleSet.GetHighestTarget(); target != "fs" {
+		t.Errorf("Target mismatch: Expected fs Got %s", target)
+	}
+}
+
+func TestParseInvalidRuleSelector(t *testing.T) {
+	for _, expression := range []string{"", "tag:", "tag:a &&", "(tag:a", "tag:a)", "a & b", "[abc"} {
+		if _, err := rules.ParseRuleSelector(expression); err == nil {
+			t.Errorf("Expected error for '%s', got nil", expression)
+		}
+	}
+}
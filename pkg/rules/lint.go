@@ -0,0 +1,165 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/runner"
+)
+
+const (
+	LintLevelError   = "error"
+	LintLevelWarning = "warning"
+)
+
+type LintFinding struct {
+	RuleId  string `json:"rule_id"`
+	Source  string `json:"source"`
+	Check   string `json:"check"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+type LintResult struct {
+	Ruleset  string        `json:"ruleset"`
+	Findings []LintFinding `json:"findings"`
+	Errors   int           `json:"errors"`
+	Warnings int           `json:"warnings"`
+}
+
+// Util modules and the lowest target whose runner imports them
+var utilTargets = map[string]string{
+	"fs_util": "fs",
+	"os_util": "os",
+}
+
+var utilUsagePattern = regexp.MustCompile(`\b(command_util|fs_util|os_util|fix_util)\b`)
+var fixFinishPattern = regexp.MustCompile(`\bfix_util\s*\.\s*finish\s*\(`)
+var reportSeverityPattern = regexp.MustCompile(`\bseverity\s*=\s*['"]([^'"]*)['"]`)
+
+// Statically check the ruleset and all its includes
+func LintRuleset(location string) (LintResult, error) {
+	loader, err := newRulesetLoader(location)
+	if err != nil {
+		return LintResult{}, err
+	}
+	loader.strict = true
+	ruleSet, err := loader.loadRulesetWithIncludes(location, []string{})
+	if err != nil {
+		return LintResult{}, err
+	}
+	result := LintResult{Ruleset: location, Findings: []LintFinding{}}
+	result.add(lintDuplicateIds(loader.loadedRulesets)...)
+	for _, rule := range ruleSet.Rules {
+		result.add(lintRule(rule)...)
+	}
+	return result, nil
+}
+
+func (lr *LintResult) add(findings ...LintFinding) {
+	for _, finding := range findings {
+		switch finding.Level {
+		case LintLevelError:
+			lr.Errors++
+		case LintLevelWarning:
+			lr.Warnings++
+		}
+		lr.Findings = append(lr.Findings, finding)
+	}
+}
+
+// Duplicates within a ruleset are errors, across rulesets the included rule is shadowed which may be intended
+// Rulesets are loaded in order of precedence, so the first definition is the one that is used
+func lintDuplicateIds(loadedRulesets []RuleSet) []LintFinding {
+	findings := []LintFinding{}
+	definedIn := make(map[string]RuleSource)
+	for _, ruleSet := range loadedRulesets {
+		seen := make(map[string]bool)
+		for _, rule := range ruleSet.Rules {
+			if seen[rule.Id] {
+				findings = append(findings, LintFinding{
+					RuleId:  rule.Id,
+					Source:  rule.Source.String(),
+					Check:   "duplicate-id",
+					Level:   LintLevelError,
+					Message: "Rule id is defined multiple times in the same ruleset",
+				})
+				continue
+			}
+			seen[rule.Id] = true
+			if first, ok := definedIn[rule.Id]; ok && first.Location != rule.Source.Location {
+				findings = append(findings, LintFinding{
+					RuleId:  rule.Id,
+					Source:  rule.Source.String(),
+					Check:   "duplicate-id",
+					Level:   LintLevelWarning,
+					Message: fmt.Sprintf("Rule id is already defined in %s which takes precedence, this definition is not used", first.String()),
+				})
+				continue
+			}
+			definedIn[rule.Id] = rule.Source
+		}
+	}
+	return findings
+}
+
+func lintRule(rule *Rule) []LintFinding {
+	findings := []LintFinding{}
+	newFinding := func(check, level, message string) LintFinding {
+		return LintFinding{RuleId: rule.Id, Source: rule.Source.String(), Check: check, Level: level, Message: message}
+	}
+
+	if err := runner.CheckSyntax(rule.Instruction); err != nil {
+		findings = append(findings, newFinding("python-syntax", LintLevelError, fmt.Sprintf("instruction: %s", err.Error())))
+	}
+	if !strings.Contains(rule.Instruction, "assert") && !strings.Contains(rule.Instruction, "report(") {
+		findings = append(findings, newFinding("missing-assert", LintLevelWarning, "Instruction does not contain an assert or report, the rule can never fail"))
+	}
+	for _, match := range reportSeverityPattern.FindAllStringSubmatch(rule.Instruction, -1) {
+		if err := VerifySeverity(match[1]); err != nil {
+			findings = append(findings, newFinding("invalid-severity", LintLevelError, fmt.Sprintf("Reported severity: %s", err.Error())))
+		}
+	}
+	for _, util := range utilUsagePattern.FindAllString(rule.Instruction, -1) {
+		if util == "fix_util" {
+			findings = append(findings, newFinding("unavailable-util", LintLevelError, "fix_util is only available in the fix instruction"))
+			continue
+		}
+		requiredTarget, ok := utilTargets[util]
+		if !ok || runner.GetTargetScore(rule.Target) >= runner.GetTargetScore(requiredTarget) {
+			continue
+		}
+		findings = append(findings, newFinding("unavailable-util", LintLevelError, fmt.Sprintf("%s is used but only imported for target %s or higher (Target: %s)", util, requiredTarget, rule.Target)))
+	}
+
+	if len(rule.FixInstruction) == 0 {
+		return dedupeFindings(findings)
+	}
+	if err := runner.CheckSyntax(rule.FixInstruction); err != nil {
+		findings = append(findings, newFinding("python-syntax", LintLevelError, fmt.Sprintf("fix_instruction: %s", err.Error())))
+	}
+	if !fixFinishPattern.MatchString(rule.FixInstruction) {
+		findings = append(findings, newFinding("missing-finish", LintLevelError, "Fix instruction never calls fix_util.finish(), the fix is never written"))
+	}
+	for _, util := range utilUsagePattern.FindAllString(rule.FixInstruction, -1) {
+		if _, ok := utilTargets[util]; ok {
+			findings = append(findings, newFinding("unavailable-util", LintLevelError, fmt.Sprintf("%s is not available in the fix instruction", util)))
+		}
+	}
+	return dedupeFindings(findings)
+}
+
+// A util that is used multiple times should only be reported once
+func dedupeFindings(findings []LintFinding) []LintFinding {
+	seen := make(map[LintFinding]bool)
+	result := []LintFinding{}
+	for _, finding := range findings {
+		if seen[finding] {
+			continue
+		}
+		seen[finding] = true
+		result = append(result, finding)
+	}
+	return result
+}
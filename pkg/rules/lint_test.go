@@ -0,0 +1,122 @@
+package rules_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/rules"
+)
+
+var lintIncludedRuleset = `
+name: included
+rules:
+  - category: Negative
+    instruction: |
+        assert(True)
+    description: Shadowed by the including ruleset
+    id: shadowed
+    target: command
+`
+
+var lintRuleset = `
+name: lint
+include:
+  - %s
+rules:
+  - category: Negative
+    instruction: |
+        assert(True)
+    description: Valid
+    id: valid
+    target: fs
+    fix_instruction: |
+        fix_util.finish()
+  - category: Negative
+    instruction: |
+        assert(True)
+    description: Shadows included rule
+    id: shadowed
+    target: command
+  - category: Negative
+    instruction: |
+        assert(True
+    description: Syntax error
+    id: syntax
+    target: command
+  - category: Negative
+    instruction: |
+        assert(fs_util.name() == "fs_util")
+        assert(os_util.name() == "os_util")
+    description: Uses os util with lower target
+    id: target_mismatch
+    target: fs
+  - category: Negative
+    instruction: |
+        assert(False)
+    description: Fix is never written
+    id: missing_finish
+    target: command
+    fix_instruction: |
+        fix_util.create_user("sample")
+  - category: Negative
+    instruction: |
+        assert(False)
+    description: Duplicated
+    id: duplicate
+    target: command
+  - category: Negative
+    instruction: |
+        assert(False)
+    description: Duplicated
+    id: duplicate
+    target: command
+  - category: Negative
+    instruction: |
+        report("Unknown severity", severity="urgent")
+    description: Reports with an unknown severity
+    id: report_severity
+    target: command
+`
+
+func TestLintRuleset(t *testing.T) {
+	dir := t.TempDir()
+	writeRulesetFiles(t, dir, map[string]string{
+		"included.yaml": lintIncludedRuleset,
+		"lint.yaml":     fmt.Sprintf(lintRuleset, filepath.Join(dir, "included.yaml")),
+	})
+
+	result, err := rules.LintRuleset(filepath.Join(dir, "lint.yaml"))
+	if err != nil {
+		t.Fatalf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+
+	expected := []struct {
+		ruleId string
+		check  string
+		level  string
+	}{
+		{"shadowed", "duplicate-id", rules.LintLevelWarning},
+		{"duplicate", "duplicate-id", rules.LintLevelError},
+		{"syntax", "python-syntax", rules.LintLevelError},
+		{"target_mismatch", "unavailable-util", rules.LintLevelError},
+		{"missing_finish", "missing-finish", rules.LintLevelError},
+		{"report_severity", "invalid-severity", rules.LintLevelError},
+	}
+	for _, e := range expected {
+		if !slices.ContainsFunc(result.Findings, func(f rules.LintFinding) bool {
+			return f.RuleId == e.ruleId && f.Check == e.check && f.Level == e.level
+		}) {
+			t.Errorf("Missing finding: %s %s %s (Got %+v)", e.ruleId, e.check, e.level, result.Findings)
+		}
+	}
+	for _, finding := range result.Findings {
+		if finding.RuleId == "valid" {
+			t.Errorf("Unexpected finding for valid rule: %+v", finding)
+		}
+	}
+	if result.Errors != 5 || result.Warnings != 1 {
+		t.Errorf("Count mismatch: Expected 5 errors 1 warning Got %d errors %d warnings", result.Errors, result.Warnings)
+	}
+}
@@ -0,0 +1,85 @@
+package rules
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Revision used if a repository location does not specify one
+const DefaultRevision = "main"
+
+// Ruleset location within a git repository
+// Format: <repository url ending in .git>[@<branch, tag or commit>]!<path>
+type RepositoryLocation struct {
+	URL      string
+	Revision string
+	Path     string
+}
+
+func ParseRepositoryLocation(location string) (RepositoryLocation, error) {
+	offset := 0
+	for {
+		index := strings.Index(location[offset:], ".git")
+		if index == -1 {
+			return RepositoryLocation{}, fmt.Errorf(".git!<path> not contained in url %s", location)
+		}
+		end := offset + index + len(".git")
+		offset = end
+		if end == len(location) {
+			continue
+		}
+		switch location[end] {
+		case '!':
+			return newRepositoryLocation(location, location[:end], "", location[end+1:])
+		case '@':
+			revision, internalPath, found := strings.Cut(location[end+1:], "!")
+			if !found {
+				return RepositoryLocation{}, fmt.Errorf(".git@<revision>!<path> not contained in url %s", location)
+			}
+			if len(revision) == 0 {
+				return RepositoryLocation{}, fmt.Errorf("Empty revision in url %s", location)
+			}
+			return newRepositoryLocation(location, location[:end], revision, internalPath)
+		}
+	}
+}
+
+func newRepositoryLocation(location, url, revision, internalPath string) (RepositoryLocation, error) {
+	if len(internalPath) == 0 {
+		return RepositoryLocation{}, fmt.Errorf("No path specified in url %s", location)
+	}
+	return RepositoryLocation{URL: url, Revision: revision, Path: internalPath}, nil
+}
+
+func (rl RepositoryLocation) GetRevision() string {
+	if len(rl.Revision) == 0 {
+		return DefaultRevision
+	}
+	return rl.Revision
+}
+
+func (rl RepositoryLocation) String() string {
+	if len(rl.Revision) == 0 {
+		return fmt.Sprintf("%s!%s", rl.URL, rl.Path)
+	}
+	return fmt.Sprintf("%s@%s!%s", rl.URL, rl.Revision, rl.Path)
+}
+
+// Includes of a git hosted ruleset that are not repository locations themselves are resolved within the same repository and revision
+// Relative paths are relative to the including ruleset, absolute paths are relative to the repository root
+func ResolveIncludeLocation(parent, include string) string {
+	if !isRepositoryLocation(parent) || isRepositoryLocation(include) {
+		return include
+	}
+	parentLocation, err := ParseRepositoryLocation(parent)
+	if err != nil {
+		return include
+	}
+	if path.IsAbs(include) {
+		parentLocation.Path = strings.TrimPrefix(path.Clean(include), "/")
+	} else {
+		parentLocation.Path = path.Join(path.Dir(parentLocation.Path), include)
+	}
+	return parentLocation.String()
+}
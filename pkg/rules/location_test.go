@@ -0,0 +1,72 @@
+package rules_test
+
+import (
+	"testing"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/rules"
+)
+
+func TestParseRepositoryLocation(t *testing.T) {
+	expected := map[string]rules.RepositoryLocation{
+		"https://github.com/org/policies.git!base.yaml": {
+			URL:  "https://github.com/org/policies.git",
+			Path: "base.yaml",
+		},
+		"git@github.com:org/policies.git@v1.4.0!policies/base.yaml": {
+			URL:      "git@github.com:org/policies.git",
+			Revision: "v1.4.0",
+			Path:     "policies/base.yaml",
+		},
+		"https://github.com/org/org.github.io.git@3f1c9e0b6a7d4e2f8b1a0c9d8e7f6a5b4c3d2e1f!base.yaml": {
+			URL:      "https://github.com/org/org.github.io.git",
+			Revision: "3f1c9e0b6a7d4e2f8b1a0c9d8e7f6a5b4c3d2e1f",
+			Path:     "base.yaml",
+		},
+	}
+	for input, expectedLocation := range expected {
+		actual, err := rules.ParseRepositoryLocation(input)
+		if err != nil {
+			t.Errorf("Error mismatch for %s: Expected nil Got '%s'", input, err.Error())
+			continue
+		}
+		if actual != expectedLocation {
+			t.Errorf("Location mismatch: Expected %+v Got %+v", expectedLocation, actual)
+		}
+		if actual.String() != input {
+			t.Errorf("String mismatch: Expected %s Got %s", input, actual.String())
+		}
+	}
+}
+
+func TestParseInvalidRepositoryLocation(t *testing.T) {
+	for _, input := range []string{
+		"https://github.com/org/policies.git",
+		"https://github.com/org/policies.g!base.yaml",
+		"https://github.com/org/policies.git@v1.4.0",
+		"https://github.com/org/policies.git@!base.yaml",
+		"https://github.com/org/policies.git!",
+	} {
+		if _, err := rules.ParseRepositoryLocation(input); err == nil {
+			t.Errorf("Expected error for %s, got nil", input)
+		}
+	}
+}
+
+func TestResolveIncludeLocation(t *testing.T) {
+	expected := []struct {
+		parent   string
+		include  string
+		expected string
+	}{
+		{"./base.yaml", "./other.yaml", "./other.yaml"},
+		{"https://github.com/org/policies.git@v1!policies/base.yaml", "common.yaml", "https://github.com/org/policies.git@v1!policies/common.yaml"},
+		{"https://github.com/org/policies.git@v1!policies/base.yaml", "../shared/common.yaml", "https://github.com/org/policies.git@v1!shared/common.yaml"},
+		{"https://github.com/org/policies.git!policies/base.yaml", "/root.yaml", "https://github.com/org/policies.git!root.yaml"},
+		{"https://github.com/org/policies.git!base.yaml", "git@github.com:org/other.git!x.yaml", "git@github.com:org/other.git!x.yaml"},
+	}
+	for _, testCase := range expected {
+		if actual := rules.ResolveIncludeLocation(testCase.parent, testCase.include); actual != testCase.expected {
+			t.Errorf("Resolved location mismatch: Expected %s Got %s", testCase.expected, actual)
+		}
+	}
+}
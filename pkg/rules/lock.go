@@ -0,0 +1,103 @@
+package rules
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+const lockfileVersion = 1
+
+var ErrLockMismatch = errors.New("Ruleset does not match lockfile")
+
+// Pins every remote ruleset that is part of a ruleset to a commit and content digest
+type Lockfile struct {
+	Version  int             `yaml:"version"`
+	Rulesets []LockedRuleset `yaml:"rulesets"`
+}
+
+type LockedRuleset struct {
+	// Location as referenced, i.e. including the unresolved revision
+	Location string `yaml:"location"`
+	Commit   string `yaml:"commit"`
+	Digest   string `yaml:"digest"`
+}
+
+// The lockfile is placed beside the ruleset: policies.yaml -> policies.lock.yaml
+func GetLockfilePath(rulesetPath string) string {
+	extension := filepath.Ext(rulesetPath)
+	return strings.TrimSuffix(rulesetPath, extension) + ".lock" + extension
+}
+
+func GetDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func ReadLockfile(path string) (Lockfile, error) {
+	var lockfile Lockfile
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return lockfile, err
+	}
+	if err = yaml.Unmarshal(data, &lockfile); err != nil {
+		return lockfile, fmt.Errorf("Could not parse lockfile %s: %w", path, err)
+	}
+	if lockfile.Version != lockfileVersion {
+		return lockfile, fmt.Errorf("Unsupported lockfile version %d in %s (Supported: %d)", lockfile.Version, path, lockfileVersion)
+	}
+	return lockfile, nil
+}
+
+func (lf Lockfile) Write(path string) error {
+	data, err := yaml.Marshal(lf)
+	if err != nil {
+		return err
+	}
+	header := "# Generated by whale-watcher rules lock. Do not edit manually\n"
+	return os.WriteFile(path, append([]byte(header), data...), 0644)
+}
+
+func (lf Lockfile) Get(location string) (LockedRuleset, error) {
+	index := slices.IndexFunc(lf.Rulesets, func(locked LockedRuleset) bool { return locked.Location == location })
+	if index == -1 {
+		return LockedRuleset{}, fmt.Errorf("%w: %s is not locked, rerun rules lock", ErrLockMismatch, location)
+	}
+	return lf.Rulesets[index], nil
+}
+
+func (lr LockedRuleset) Verify(data []byte) error {
+	if digest := GetDigest(data); digest != lr.Digest {
+		return fmt.Errorf("%w: content of %s at %s has digest %s (Locked: %s)", ErrLockMismatch, lr.Location, lr.Commit, digest, lr.Digest)
+	}
+	return nil
+}
+
+// Resolve every remote ruleset that is (transitively) part of the ruleset to its current commit and digest
+func LockRuleset(location string) (Lockfile, error) {
+	loader := rulesetLoader{strict: true}
+	if _, err := loader.loadRulesetWithIncludes(location, []string{}); err != nil {
+		return Lockfile{}, err
+	}
+	lockfile := Lockfile{Version: lockfileVersion, Rulesets: []LockedRuleset{}}
+	for _, remote := range loader.remoteRulesets {
+		if existing, err := lockfile.Get(remote.Location); err == nil {
+			if existing != remote {
+				return Lockfile{}, fmt.Errorf("%s resolved to different versions while locking", remote.Location)
+			}
+			continue
+		}
+		log.Info().Str("location", remote.Location).Str("commit", remote.Commit).Msg("Locked remote ruleset")
+		lockfile.Rulesets = append(lockfile.Rulesets, remote)
+	}
+	slices.SortFunc(lockfile.Rulesets, func(a, b LockedRuleset) int { return strings.Compare(a.Location, b.Location) })
+	return lockfile, nil
+}
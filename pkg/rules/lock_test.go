@@ -0,0 +1,125 @@
+package rules_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/rules"
+	"github.com/spf13/viper"
+)
+
+func TestGetLockfilePath(t *testing.T) {
+	expected := map[string]string{
+		"policies.yaml":        "policies.lock.yaml",
+		"./dir/policies.yml":   "./dir/policies.lock.yml",
+		"/abs/policies":        "/abs/policies.lock",
+		"./dir.d/policies.yml": "./dir.d/policies.lock.yml",
+	}
+	for input, path := range expected {
+		if actual := rules.GetLockfilePath(input); actual != path {
+			t.Errorf("Lockfile path mismatch: Expected %s Got %s", path, actual)
+		}
+	}
+}
+
+func TestLockfileRoundtrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policies.lock.yaml")
+	expected := rules.Lockfile{
+		Version: 1,
+		Rulesets: []rules.LockedRuleset{
+			{
+				Location: "https://github.com/org/policies.git@v1!base.yaml",
+				Commit:   "3f1c9e0b6a7d4e2f8b1a0c9d8e7f6a5b4c3d2e1f",
+				Digest:   rules.GetDigest([]byte("content")),
+			},
+		},
+	}
+	if err := expected.Write(path); err != nil {
+		t.Fatal(err)
+	}
+	actual, err := rules.ReadLockfile(path)
+	if err != nil {
+		t.Fatalf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Lockfile mismatch: Expected %+v Got %+v", expected, actual)
+	}
+}
+
+func TestLockedRulesetVerify(t *testing.T) {
+	locked := rules.LockedRuleset{Location: "https://github.com/org/policies.git!base.yaml", Digest: rules.GetDigest([]byte("content"))}
+	if err := locked.Verify([]byte("content")); err != nil {
+		t.Errorf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+	if err := locked.Verify([]byte("changed content")); !errors.Is(err, rules.ErrLockMismatch) {
+		t.Errorf("Error mismatch: Expected lock mismatch Got '%v'", err)
+	}
+	if _, err := (rules.Lockfile{}).Get(locked.Location); !errors.Is(err, rules.ErrLockMismatch) {
+		t.Errorf("Error mismatch: Expected lock mismatch Got '%v'", err)
+	}
+}
+
+func TestLockLocalRuleset(t *testing.T) {
+	dir := t.TempDir()
+	writeRulesetFiles(t, dir, map[string]string{
+		"base.yaml": overriddenBaseRuleset,
+	})
+	lockfile, err := rules.LockRuleset(filepath.Join(dir, "base.yaml"))
+	if err != nil {
+		t.Fatalf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+	if len(lockfile.Rulesets) != 0 {
+		t.Errorf("Locked count mismatch: Expected 0 Got %d", len(lockfile.Rulesets))
+	}
+}
+
+func TestLockMissingInclude(t *testing.T) {
+	dir := t.TempDir()
+	writeRulesetFiles(t, dir, map[string]string{
+		"a.yaml": getRulesetWithInclude("a", filepath.Join(dir, "missing.yaml"), "a_rule"),
+	})
+	if _, err := rules.LockRuleset(filepath.Join(dir, "a.yaml")); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+func TestLoadRulesetInvalidLockfile(t *testing.T) {
+	dir := t.TempDir()
+	writeRulesetFiles(t, dir, map[string]string{
+		"base.yaml":      overriddenBaseRuleset,
+		"base.lock.yaml": "version: 42\nrulesets: []\n",
+	})
+	if _, err := rules.LoadRuleset(filepath.Join(dir, "base.yaml")); err == nil {
+		t.Error("Expected error, got nil")
+	}
+	if err := os.Remove(filepath.Join(dir, "base.lock.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rules.LoadRuleset(filepath.Join(dir, "base.yaml")); err != nil {
+		t.Errorf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+}
+
+func TestLoadRulesetConfiguredLockfile(t *testing.T) {
+	dir := t.TempDir()
+	writeRulesetFiles(t, dir, map[string]string{
+		"base.yaml":        overriddenBaseRuleset,
+		"base.lock.yaml":   "version: 42\nrulesets: []\n",
+		"custom.lock.yaml": "version: 1\nrulesets: []\n",
+	})
+	defer viper.Set("lockfile", "")
+
+	// The configured lockfile takes precedence over the one beside the ruleset
+	viper.Set("lockfile", filepath.Join(dir, "custom.lock.yaml"))
+	if _, err := rules.LoadRuleset(filepath.Join(dir, "base.yaml")); err != nil {
+		t.Errorf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+
+	viper.Set("lockfile", filepath.Join(dir, "missing.lock.yaml"))
+	if _, err := rules.LoadRuleset(filepath.Join(dir, "base.yaml")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Error mismatch: Expected missing lockfile Got '%v'", err)
+	}
+}
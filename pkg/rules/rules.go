@@ -1,76 +1,179 @@
 package rules
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/coffeemakingtoaster/whale-watcher/pkg/fetcher"
 	"github.com/coffeemakingtoaster/whale-watcher/pkg/util"
 	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
 )
 
+var ErrIncludeCycle = errors.New("Include cycle detected")
+
+// Keeps track of the remote rulesets that were loaded and pins them if a lockfile is used
+type rulesetLoader struct {
+	lockfile *Lockfile
+	// Fail on includes that cannot be loaded regardless of the config
+	strict bool
+	// Every remote ruleset that was loaded
+	remoteRulesets []LockedRuleset
+	// Every ruleset that was loaded with only its own rules
+	loadedRulesets []RuleSet
+}
+
+// Load the ruleset and resolve all includes transitively
+// Precedence: rules of the set itself > later includes > earlier includes
+// Every include is fully resolved (including its own excludes and overrides) before being swallowed
+// If a lockfile is configured (lockfile) or exists beside a local ruleset, remote rulesets are loaded at their locked version
 func LoadRuleset(location string) (RuleSet, error) {
-	ruleset, err := shallowLoadRuleSet(location)
+	loader, err := newRulesetLoader(location)
+	if err != nil {
+		return RuleSet{}, err
+	}
+	return loader.loadRulesetWithIncludes(location, []string{})
+}
+
+func newRulesetLoader(location string) (*rulesetLoader, error) {
+	loader := &rulesetLoader{}
+	// A configured lockfile has to exist, it is the only way to pin the includes of remote rulesets
+	if lockfilePath := viper.GetString("lockfile"); len(lockfilePath) > 0 {
+		lockfile, err := ReadLockfile(lockfilePath)
+		if err != nil {
+			return nil, fmt.Errorf("Could not read lockfile %s: %w", lockfilePath, err)
+		}
+		log.Info().Str("lockfile", lockfilePath).Msg("Using locked versions of remote rulesets")
+		loader.lockfile = &lockfile
+		return loader, nil
+	}
+	if isRepositoryLocation(location) {
+		return loader, nil
+	}
+	lockfile, err := ReadLockfile(GetLockfilePath(location))
+	if err == nil {
+		log.Info().Str("lockfile", GetLockfilePath(location)).Msg("Using locked versions of remote rulesets")
+		loader.lockfile = &lockfile
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return loader, nil
+}
+
+func (l *rulesetLoader) loadRulesetWithIncludes(location string, includeChain []string) (RuleSet, error) {
+	key := getIncludeKey(location)
+	if slices.Contains(includeChain, key) {
+		return RuleSet{}, fmt.Errorf("%w: %s -> %s", ErrIncludeCycle, strings.Join(includeChain, " -> "), key)
+	}
+	includeChain = append(includeChain, key)
+
+	ruleset, err := l.shallowLoadRuleSet(location)
 	if err != nil {
 		return RuleSet{}, err
 	}
-	if len(ruleset.Include) == 0 {
-		return ruleset, nil
+	for _, rule := range ruleset.Rules {
+		rule.Source = RuleSource{Location: location, RuleSetName: ruleset.Name}
 	}
+	l.loadedRulesets = append(l.loadedRulesets, RuleSet{Name: ruleset.Name, Rules: slices.Clone(ruleset.Rules)})
 	for i := range ruleset.Include {
-		source := ruleset.Include[len(ruleset.Include)-1-i]
-		weakSet, err := shallowLoadRuleSet(source)
+		source := ResolveIncludeLocation(location, ruleset.Include[len(ruleset.Include)-1-i])
+		weakSet, err := l.loadRulesetWithIncludes(source, includeChain)
 		if err != nil {
+			if errors.Is(err, ErrIncludeCycle) || errors.Is(err, ErrLockMismatch) {
+				return RuleSet{}, err
+			}
+			if l.strict || viper.GetBool("strict_includes") {
+				return RuleSet{}, fmt.Errorf("Could not load included ruleset %s: %w", source, err)
+			}
 			log.Error().Err(err).Str("source", source).Str("nestingset", ruleset.Name).Msg("Could not load included ruleset from source due to an erro")
 			continue
 		}
 		ruleset.Swallow(weakSet)
 	}
+	if err = ruleset.applyOverrides(); err != nil {
+		return RuleSet{}, err
+	}
+	ruleset.applyParamOverrides()
 	return ruleset, nil
 }
 
-func shallowLoadRuleSet(location string) (RuleSet, error) {
+// Local paths are made absolute so the same file is detected regardless of how it was referenced
+func getIncludeKey(location string) string {
+	if isRepositoryLocation(location) {
+		return location
+	}
+	absolute, err := filepath.Abs(location)
+	if err != nil {
+		return location
+	}
+	return absolute
+}
+
+func isRepositoryLocation(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") || strings.HasPrefix(location, "git@")
+}
+
+func (l *rulesetLoader) shallowLoadRuleSet(location string) (RuleSet, error) {
 	if strings.HasPrefix(location, "http://") {
 		log.Debug().Msg("Provided ruleset location is a (unsafe) git repository!")
 		if !util.IsUnsafeMode() {
 			return RuleSet{}, errors.New("Could not load ruleset from unsafe repository (unsafe mode is disabled)")
 		}
-		return loadRuleSetFromRepository(location)
+		return l.loadRuleSetFromRepository(location)
 	}
 	if strings.HasPrefix(location, "https://") {
 		log.Debug().Msg("Provided ruleset location is a git repository!")
-		return loadRuleSetFromRepository(location)
+		return l.loadRuleSetFromRepository(location)
 	}
 	if strings.HasPrefix(location, "git@") {
 		log.Debug().Msg("Provided ruleset location is a ssh git repository!")
-		return loadRuleSetFromRepository(location)
+		return l.loadRuleSetFromRepository(location)
 	}
 	log.Debug().Msg("Provided ruleset location is a filepath!")
 	return loadRuleSetFromFile(location)
 }
 
-func loadRuleSetFromRepository(repositoryURL string) (RuleSet, error) {
+func (l *rulesetLoader) loadRuleSetFromRepository(repositoryURL string) (RuleSet, error) {
 	var ruleSet RuleSet
-	url, internalPath, err := separateWWRepositoryUrlIntoGitURlAndInternalPath(repositoryURL)
+	location, err := ParseRepositoryLocation(repositoryURL)
 
 	if err != nil {
 		return ruleSet, err
 	}
 
-	if !strings.HasSuffix(url, ".git") {
-		return ruleSet, fmt.Errorf("url (%s) has to end in .git", url)
+	revision := location.GetRevision()
+	var locked LockedRuleset
+	if l.lockfile != nil {
+		locked, err = l.lockfile.Get(repositoryURL)
+		if err != nil {
+			return ruleSet, err
+		}
+		revision = locked.Commit
 	}
 
-	data, err := fetcher.GetFileFromRepository(url, "main", internalPath)
+	data, commit, err := fetcher.GetFileFromRepositoryAtRevision(location.URL, revision, location.Path)
 
 	if err != nil {
 		return ruleSet, err
 	}
 
-	return LoadRuleSetFromContent(data)
+	if l.lockfile != nil {
+		if err = locked.Verify(data); err != nil {
+			return ruleSet, err
+		}
+	}
+
+	log.Debug().Str("location", repositoryURL).Str("commit", commit).Msg("Loaded ruleset from repository")
+	l.remoteRulesets = append(l.remoteRulesets, LockedRuleset{Location: repositoryURL, Commit: commit, Digest: GetDigest(data)})
+
+	return parseRuleSet(data, repositoryURL)
 }
 
 func loadRuleSetFromFile(path string) (RuleSet, error) {
@@ -78,16 +181,23 @@ func loadRuleSetFromFile(path string) (RuleSet, error) {
 	if err != nil {
 		return RuleSet{}, err
 	}
-	return LoadRuleSetFromContent(file)
+	return parseRuleSet(file, path)
 }
 
 func LoadRuleSetFromContent(data []byte) (RuleSet, error) {
+	return parseRuleSet(data, "<content>")
+}
+
+// In strict mode unknown fields are rejected, the location is used to point to the offending file
+func parseRuleSet(data []byte, location string) (RuleSet, error) {
 	var ruleSet RuleSet
 	ruleSet.targetList = make(map[string]bool)
 
-	err := yaml.Unmarshal(data, &ruleSet)
-	if err != nil {
-		return RuleSet{}, err
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(viper.GetBool("strict"))
+	err := decoder.Decode(&ruleSet)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return RuleSet{}, fmt.Errorf("%s: %w", location, err)
 	}
 	for _, v := range ruleSet.Rules {
 		err := v.AddRunner()
@@ -98,20 +208,10 @@ func LoadRuleSetFromContent(data []byte) (RuleSet, error) {
 		if err != nil {
 			return RuleSet{}, err
 		}
-		if !strings.Contains(v.Instruction, "assert") {
-			log.Warn().Str("Instruction", v.Instruction).Msg("Instruction does not contain an assert. This rule therefore will never be checked properly")
+		if !strings.Contains(v.Instruction, "assert") && !strings.Contains(v.Instruction, "report(") {
+			log.Warn().Str("Instruction", v.Instruction).Msg("Instruction does not contain an assert or report. This rule therefore will never be checked properly")
 		}
 		ruleSet.targetList[v.Target] = true
 	}
 	return ruleSet, nil
 }
-
-func separateWWRepositoryUrlIntoGitURlAndInternalPath(repositoryURl string) (string, string, error) {
-	matcher := util.NewSliceSearch[rune]([]rune(".git!"))
-	for i, c := range []rune(repositoryURl) {
-		if matcher.Match(c) {
-			return repositoryURl[:i], repositoryURl[i+1:], nil
-		}
-	}
-	return "", "", errors.New(fmt.Sprintf(".git!<path> not contained in url %s", repositoryURl))
-}
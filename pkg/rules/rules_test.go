@@ -1,10 +1,16 @@
 package rules_test
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/coffeemakingtoaster/whale-watcher/pkg/rules"
+	"github.com/spf13/viper"
 )
 
 var noAssertRuleset = `
@@ -36,6 +42,7 @@ rules:
    description: Perform a check
    id: test id2
    target: fs
+   severity: Critical
 `
 
 func TestLoadRulesetFromContent(t *testing.T) {
@@ -48,6 +55,7 @@ func TestLoadRulesetFromContent(t *testing.T) {
 				Description: "Perform a check",
 				Id:          "test id",
 				Target:      "command",
+				Severity:    "medium",
 			},
 			{
 				Category:    "positive",
@@ -55,6 +63,7 @@ func TestLoadRulesetFromContent(t *testing.T) {
 				Description: "Perform a check",
 				Id:          "test id2",
 				Target:      "fs",
+				Severity:    "critical",
 			},
 		},
 	}
@@ -95,6 +104,14 @@ func TestVerifyInvalidRuleset(t *testing.T) {
 			Id:          "test id2",
 			Target:      "fs",
 		},
+		"Severity: Invalid value urgent (Allowed: [\"info\" \"low\" \"medium\" \"high\" \"critical\"])": {
+			Category:    "positive",
+			Instruction: "assert(True == False)",
+			Description: "Perform a check",
+			Id:          "test id3",
+			Target:      "fs",
+			Severity:    "urgent",
+		},
 	}
 
 	for errorMessage, rule := range expected {
@@ -105,6 +122,23 @@ func TestVerifyInvalidRuleset(t *testing.T) {
 	}
 }
 
+func TestSeverityRank(t *testing.T) {
+	expected := map[string]int{
+		"info":     0,
+		"Low":      1,
+		"medium":   2,
+		"HIGH":     3,
+		"critical": 4,
+		"":         2,
+		"unknown":  2,
+	}
+	for severity, rank := range expected {
+		if actual := rules.SeverityRank(severity); actual != rank {
+			t.Errorf("Rank mismatch for '%s': Expected %d Got %d", severity, rank, actual)
+		}
+	}
+}
+
 func TestLoadRulesetFromInvalidGitRepositoryUrl(t *testing.T) {
 	_, err := rules.LoadRuleset("git@github.com:coffeemakingtoaster/whale-watcher.g!abc.yaml")
 	if err == nil {
@@ -119,3 +153,271 @@ func TestLoadRulesetFromGitRepositoryUrlWithoutPath(t *testing.T) {
 	}
 
 }
+
+func TestLoadRulesetParamOverrides(t *testing.T) {
+	dir := t.TempDir()
+	baseSet := `
+name: base
+rules:
+  - category: Negative
+    instruction: |
+        assert(params["registry"] in params["allowed"])
+    description: Check registry
+    id: registry
+    target: command
+    params:
+      registry: docker.io
+      allowed:
+        - docker.io
+`
+	includingSet := fmt.Sprintf(`
+name: including
+include:
+  - %s
+params:
+  registry:
+    allowed:
+      - ghcr.io
+      - quay.io
+rules: []
+`, filepath.Join(dir, "base.yaml"))
+	if err := os.WriteFile(filepath.Join(dir, "base.yaml"), []byte(baseSet), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "including.yaml"), []byte(includingSet), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := rules.LoadRuleset(filepath.Join(dir, "including.yaml"))
+	if err != nil {
+		t.Fatalf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+	if len(actual.Rules) != 1 {
+		t.Fatalf("rule count mismatch: Expected 1 Got %d", len(actual.Rules))
+	}
+	expected := map[string]any{
+		"registry": "docker.io",
+		"allowed":  []any{"ghcr.io", "quay.io"},
+	}
+	if !reflect.DeepEqual(expected, actual.Rules[0].Params) {
+		t.Errorf("Params mismatch: Expected %v Got %v", expected, actual.Rules[0].Params)
+	}
+}
+
+var overriddenBaseRuleset = `
+name: base
+rules:
+  - category: Negative
+    instruction: |
+        assert(True)
+    description: Base description
+    id: keep
+    target: command
+    severity: low
+  - category: Negative
+    instruction: |
+        assert(True)
+    description: Dropped
+    id: drop
+    target: command
+`
+
+func TestLoadRulesetOverridesAndExclude(t *testing.T) {
+	dir := t.TempDir()
+	includingSet := fmt.Sprintf(`
+name: including
+include:
+  - %s
+exclude:
+  - drop
+overrides:
+  keep:
+    description: Overridden description
+    severity: Critical
+rules: []
+`, filepath.Join(dir, "base.yaml"))
+	if err := os.WriteFile(filepath.Join(dir, "base.yaml"), []byte(overriddenBaseRuleset), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "including.yaml"), []byte(includingSet), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := rules.LoadRuleset(filepath.Join(dir, "including.yaml"))
+	if err != nil {
+		t.Fatalf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+	if len(actual.Rules) != 1 {
+		t.Fatalf("rule count mismatch: Expected 1 Got %d", len(actual.Rules))
+	}
+	if actual.Rules[0].Description != "Overridden description" {
+		t.Errorf("Description mismatch: Expected Overridden description Got %s", actual.Rules[0].Description)
+	}
+	if actual.Rules[0].Severity != "critical" {
+		t.Errorf("Severity mismatch: Expected critical Got %s", actual.Rules[0].Severity)
+	}
+}
+
+func TestLoadRulesetInvalidOverride(t *testing.T) {
+	dir := t.TempDir()
+	includingSet := fmt.Sprintf(`
+name: including
+include:
+  - %s
+overrides:
+  keep:
+    severity: urgent
+rules: []
+`, filepath.Join(dir, "base.yaml"))
+	if err := os.WriteFile(filepath.Join(dir, "base.yaml"), []byte(overriddenBaseRuleset), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "including.yaml"), []byte(includingSet), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := rules.LoadRuleset(filepath.Join(dir, "including.yaml"))
+	if err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+func TestLoadRulesetStrictIncludes(t *testing.T) {
+	dir := t.TempDir()
+	includingSet := fmt.Sprintf(`
+name: including
+include:
+  - %s
+rules: []
+`, filepath.Join(dir, "missing.yaml"))
+	if err := os.WriteFile(filepath.Join(dir, "including.yaml"), []byte(includingSet), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := rules.LoadRuleset(filepath.Join(dir, "including.yaml"))
+	if err != nil {
+		t.Errorf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+
+	viper.Set("strict_includes", true)
+	defer viper.Reset()
+
+	_, err = rules.LoadRuleset(filepath.Join(dir, "including.yaml"))
+	if err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+func writeRulesetFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func getRulesetWithInclude(name, include, id string) string {
+	return fmt.Sprintf(`
+name: %s
+include:
+  - %s
+rules:
+  - category: Negative
+    instruction: |
+        assert(True)
+    description: %s
+    id: %s
+    target: command
+`, name, include, name, id)
+}
+
+func TestLoadRulesetTransitiveIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeRulesetFiles(t, dir, map[string]string{
+		"a.yaml": getRulesetWithInclude("a", filepath.Join(dir, "b.yaml"), "a_rule"),
+		"b.yaml": getRulesetWithInclude("b", filepath.Join(dir, "c.yaml"), "b_rule"),
+		"c.yaml": `
+name: c
+rules:
+  - category: Negative
+    instruction: |
+        assert(True)
+    description: c
+    id: c_rule
+    target: command
+  - category: Negative
+    instruction: |
+        assert(True)
+    description: c
+    id: b_rule
+    target: command
+`,
+	})
+
+	actual, err := rules.LoadRuleset(filepath.Join(dir, "a.yaml"))
+	if err != nil {
+		t.Fatalf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+	expected := map[string]string{
+		"a_rule": "a",
+		"b_rule": "b",
+		"c_rule": "c",
+	}
+	if len(actual.Rules) != len(expected) {
+		t.Fatalf("rule count mismatch: Expected %d Got %d", len(expected), len(actual.Rules))
+	}
+	for _, rule := range actual.Rules {
+		if rule.Description != expected[rule.Id] {
+			t.Errorf("Precedence mismatch for %s: Expected %s Got %s", rule.Id, expected[rule.Id], rule.Description)
+		}
+		if rule.Source.RuleSetName != expected[rule.Id] {
+			t.Errorf("Source mismatch for %s: Expected %s Got %s", rule.Id, expected[rule.Id], rule.Source.RuleSetName)
+		}
+		if rule.Source.Location != filepath.Join(dir, expected[rule.Id]+".yaml") {
+			t.Errorf("Source location mismatch for %s: Expected %s Got %s", rule.Id, filepath.Join(dir, expected[rule.Id]+".yaml"), rule.Source.Location)
+		}
+	}
+}
+
+func TestLoadRulesetIncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+	writeRulesetFiles(t, dir, map[string]string{
+		"a.yaml": getRulesetWithInclude("a", filepath.Join(dir, "b.yaml"), "a_rule"),
+		"b.yaml": getRulesetWithInclude("b", filepath.Join(dir, "a.yaml"), "b_rule"),
+	})
+
+	_, err := rules.LoadRuleset(filepath.Join(dir, "a.yaml"))
+	if !errors.Is(err, rules.ErrIncludeCycle) {
+		t.Errorf("Error mismatch: Expected include cycle Got '%v'", err)
+	}
+}
+
+func TestLoadRulesetFromContentStrict(t *testing.T) {
+	typoRuleset := `
+name: typo ruleset
+rules:
+  - category: Negative
+    instruction: |
+        assert(True)
+    description: Perform a check
+    id: typo
+    target: command
+    fix_instructions: |
+        fix_util.finish()
+`
+	if _, err := rules.LoadRuleSetFromContent([]byte(typoRuleset)); err != nil {
+		t.Errorf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+
+	viper.Set("strict", true)
+	defer viper.Reset()
+
+	_, err := rules.LoadRuleSetFromContent([]byte(typoRuleset))
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	if !strings.Contains(err.Error(), "line 10: field fix_instructions not found") {
+		t.Errorf("Error mismatch: Expected line and field in error Got '%s'", err.Error())
+	}
+}
@@ -0,0 +1,149 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/container"
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/runner"
+)
+
+const (
+	TestExpectPass      = "pass"
+	TestExpectViolation = "violation"
+)
+
+var allowedTestExpectations = []string{TestExpectPass, TestExpectViolation}
+
+const (
+	TestStatusPassed = "passed"
+	TestStatusFailed = "failed"
+	TestStatusError  = "error"
+)
+
+// Test case of a rule, the rule is run against the inline Dockerfile instead of a real target
+type RuleTest struct {
+	Name       string `yaml:"name" desc:"Name of the test case"`
+	Dockerfile string `yaml:"dockerfile" desc:"Dockerfile content the rule is run against"`
+	Expect     string `yaml:"expect" desc:"Expected outcome, pass or violation"`
+	// Only checked if the rule is expected to be violated
+	Fixed string `yaml:"fixed" desc:"Expected Dockerfile after running the fix instruction (only for expect: violation)"`
+}
+
+type RuleTestResult struct {
+	RuleId  string `json:"rule_id"`
+	Source  string `json:"source"`
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+type RuleTestReport struct {
+	Ruleset string           `json:"ruleset"`
+	Results []RuleTestResult `json:"results"`
+	Passed  int              `json:"passed"`
+	Failed  int              `json:"failed"`
+	Errors  int              `json:"errors"`
+}
+
+// Name of the test, falls back to its position in the rule
+func (rt RuleTest) GetName(index int) string {
+	if len(rt.Name) > 0 {
+		return rt.Name
+	}
+	return fmt.Sprintf("#%d", index+1)
+}
+
+func (rt *RuleTest) verify(rule *Rule) error {
+	if len(strings.TrimSpace(rt.Dockerfile)) == 0 {
+		return errors.New("No dockerfile set")
+	}
+	rt.Expect = strings.ToLower(rt.Expect)
+	if err := isInAllowed(rt.Expect, allowedTestExpectations); err != nil {
+		return fmt.Errorf("Expect: %s", err.Error())
+	}
+	if len(rt.Fixed) > 0 {
+		if rt.Expect != TestExpectViolation {
+			return errors.New("Fixed is only checked for tests that expect a violation")
+		}
+		if len(rule.FixInstruction) == 0 {
+			return errors.New("Fixed is set but the rule has no fix instruction")
+		}
+	}
+	return nil
+}
+
+// Run the tests of all rules in the ruleset (including included rules)
+func (rs *RuleSet) RunTests() RuleTestReport {
+	report := RuleTestReport{Ruleset: rs.Name, Results: []RuleTestResult{}}
+	for _, rule := range rs.Rules {
+		for i, test := range rule.Tests {
+			result := RuleTestResult{RuleId: rule.Id, Source: rule.Source.String(), Name: test.GetName(i)}
+			result.Status, result.Message = rule.runTest(test)
+			switch result.Status {
+			case TestStatusPassed:
+				report.Passed++
+			case TestStatusFailed:
+				report.Failed++
+			default:
+				report.Errors++
+			}
+			report.Results = append(report.Results, result)
+		}
+	}
+	return report
+}
+
+func (r *Rule) runTest(test RuleTest) (string, string) {
+	// There is no image for tests so fs_util and os_util cannot be set up
+	if r.Target != "command" {
+		return TestStatusError, fmt.Sprintf("Tests are only supported for rules with target command (Got: %s)", r.Target)
+	}
+	dockerfile := splitDockerfile(test.Dockerfile)
+	passed, err := runner.RunTest(dockerfile, r.Instruction, r.Params)
+	if err != nil {
+		return TestStatusError, err.Error()
+	}
+	if passed && test.Expect == TestExpectViolation {
+		return TestStatusFailed, "Expected a violation but the rule passed"
+	}
+	if !passed && test.Expect == TestExpectPass {
+		return TestStatusFailed, "Expected the rule to pass but it was violated"
+	}
+	if len(test.Fixed) == 0 {
+		return TestStatusPassed, ""
+	}
+	fixed, err := runner.RunFixTest(dockerfile, r.FixInstruction, r.Params)
+	if err != nil {
+		return TestStatusError, err.Error()
+	}
+	expected, err := normalizeDockerfile(splitDockerfile(test.Fixed))
+	if err != nil {
+		return TestStatusError, fmt.Sprintf("Could not parse fixed: %s", err.Error())
+	}
+	actual := splitDockerfile(strings.Join(fixed, "\n"))
+	if !slices.Equal(actual, expected) {
+		return TestStatusFailed, fmt.Sprintf("Fixed Dockerfile mismatch:\nExpected:\n%s\nGot:\n%s", strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+	}
+	return TestStatusPassed, ""
+}
+
+// The fixed Dockerfile is reconstructed from the ast, so the expected one is brought into the same format
+func normalizeDockerfile(lines []string) ([]string, error) {
+	root, err := container.GetDockerfileInputAST(lines)
+	if err != nil {
+		return nil, err
+	}
+	return splitDockerfile(strings.Join(root.Reconstruct(), "\n")), nil
+}
+
+// Trailing whitespace is not significant for comparing Dockerfiles
+func splitDockerfile(content string) []string {
+	lines := strings.Split(strings.TrimRight(content, " \t\n"), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " \t")
+	}
+	return lines
+}
@@ -0,0 +1,87 @@
+package rules_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/rules"
+)
+
+func getRulesetWithTest(target, fixInstruction, test string) string {
+	return `
+name: tested ruleset
+rules:
+  - category: Negative
+    instruction: |
+        assert(command_util.uses_command("curl"))
+    description: Uses curl
+    id: uses_curl
+    target: ` + target + `
+    fix_instruction: "` + fixInstruction + `"
+    tests:
+` + test
+}
+
+func TestLoadRuleTests(t *testing.T) {
+	ruleSet, err := rules.LoadRuleSetFromContent([]byte(getRulesetWithTest("command", "fix_util.finish()", `
+      - name: curl used
+        dockerfile: |
+          FROM ubuntu
+          RUN curl -f example.com
+        expect: Pass
+      - dockerfile: FROM ubuntu
+        expect: violation
+        fixed: |
+          FROM ubuntu
+`)))
+	if err != nil {
+		t.Fatalf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+	tests := ruleSet.Rules[0].Tests
+	if len(tests) != 2 {
+		t.Fatalf("Test count mismatch: Expected 2 Got %d", len(tests))
+	}
+	if tests[0].Expect != rules.TestExpectPass {
+		t.Errorf("Expect mismatch: Expected %s Got %s", rules.TestExpectPass, tests[0].Expect)
+	}
+	if name := tests[1].GetName(1); name != "#2" {
+		t.Errorf("Name mismatch: Expected #2 Got %s", name)
+	}
+}
+
+func TestLoadInvalidRuleTests(t *testing.T) {
+	expected := []struct {
+		fixInstruction string
+		test           string
+		expected       string
+	}{
+		{"", "      - dockerfile: FROM ubuntu\n        expect: fail\n", "Expect: Invalid value fail"},
+		{"", "      - expect: pass\n", "No dockerfile set"},
+		{"fix_util.finish()", "      - dockerfile: FROM ubuntu\n        expect: pass\n        fixed: FROM ubuntu\n", "only checked for tests that expect a violation"},
+		{"", "      - dockerfile: FROM ubuntu\n        expect: violation\n        fixed: FROM ubuntu\n", "no fix instruction"},
+	}
+	for _, testCase := range expected {
+		_, err := rules.LoadRuleSetFromContent([]byte(getRulesetWithTest("command", testCase.fixInstruction, testCase.test)))
+		if err == nil {
+			t.Errorf("Expected error containing '%s', got nil", testCase.expected)
+			continue
+		}
+		if !strings.Contains(err.Error(), testCase.expected) {
+			t.Errorf("Error mismatch: Expected '%s' in '%s'", testCase.expected, err.Error())
+		}
+	}
+}
+
+func TestRunTestsUnsupportedTarget(t *testing.T) {
+	ruleSet, err := rules.LoadRuleSetFromContent([]byte(getRulesetWithTest("fs", "", "      - dockerfile: FROM ubuntu\n        expect: pass\n")))
+	if err != nil {
+		t.Fatalf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+	report := ruleSet.RunTests()
+	if report.Errors != 1 || report.Passed != 0 || report.Failed != 0 {
+		t.Errorf("Report mismatch: Expected 1 error Got %d passed %d failed %d errors", report.Passed, report.Failed, report.Errors)
+	}
+	if report.Results[0].Status != rules.TestStatusError || report.Results[0].RuleId != "uses_curl" {
+		t.Errorf("Result mismatch: Expected error for uses_curl Got %s for %s", report.Results[0].Status, report.Results[0].RuleId)
+	}
+}
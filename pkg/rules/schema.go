@@ -0,0 +1,27 @@
+package rules
+
+import (
+	"reflect"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/util"
+)
+
+// JSON schema of the ruleset format, generated from the ruleset types
+func GetRulesetSchema() *util.JSONSchema {
+	schema := util.GenerateJSONSchema(reflect.TypeOf(RuleSet{}), "yaml")
+	schema.Schema = util.JSONSchemaDraft
+	schema.Title = "whale-watcher ruleset"
+
+	rule := schema.Properties["rules"].Items
+	rule.Required = []string{"id", "category", "target", "instruction"}
+	rule.Properties["category"].Enum = util.CaseInsensitiveEnum(allowedCategories)
+	rule.Properties["target"].Enum = util.CaseInsensitiveEnum(allowedTargets)
+	rule.Properties["severity"].Enum = util.CaseInsensitiveEnum(allowedSeverities)
+	test := rule.Properties["tests"].Items
+	test.Required = []string{"dockerfile", "expect"}
+	test.Properties["expect"].Enum = util.CaseInsensitiveEnum(allowedTestExpectations)
+
+	override := schema.Properties["overrides"].AdditionalProperties.(*util.JSONSchema)
+	override.Properties["severity"].Enum = util.CaseInsensitiveEnum(allowedSeverities)
+	return schema
+}
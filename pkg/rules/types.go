@@ -3,6 +3,7 @@ package rules
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"slices"
 	"strings"
@@ -15,29 +16,92 @@ import (
 var allowedCategories = []string{"negative", "positive"}
 var allowedTargets = []string{"command", "os", "fs"}
 
+// Ordered from lowest to highest
+var allowedSeverities = []string{"info", "low", "medium", "high", "critical"}
+
+// Severity used for rules that do not specify one
+const DefaultSeverity = "medium"
+
+// Result of evaluating a rule
+type Outcome int
+
+const (
+	OutcomePassed Outcome = iota
+	// An assertion failed or findings were reported
+	OutcomeViolated
+	// The rule could not be evaluated, e.g. a util panicked or the interpreter crashed
+	OutcomeErrored
+)
+
 type ViolationInfo struct {
 	Details string
 	Fix     string
+	// Locations attached by the rule using set_location
+	Locations []runner.Location
+	// Findings added by the rule using report, each one is a separate violation
+	Reports []runner.Report
+	// Exception that failed or broke the rule, nil if the rule only reported findings
+	Exception *runner.Exception
+	// Time spent preparing the run, set for every outcome
+	Timings runner.Timings
 }
 
 type RuleSet struct {
-	Name       string   `yaml:"name"`
-	Include    []string `yaml:"include"`
-	Rules      []*Rule  `yaml:"rules"`
+	Name    string   `yaml:"name" desc:"Name of the ruleset"`
+	Version string   `yaml:"version" desc:"Version of the ruleset, e.g. a release tag. Shown in pull requests"`
+	Include []string `yaml:"include" desc:"Rulesets to include. File paths or <repository url ending in .git>[@<revision>]!<path>"`
+	Rules   []*Rule  `yaml:"rules" desc:"Rules of this ruleset, these take precedence over included rules"`
+	// Parameter overrides for rules (including included ones) identified via ID
+	Params map[string]map[string]any `yaml:"params" desc:"Parameter overrides for rules identified via ID"`
+	// IDs of included rules that should not be taken over
+	Exclude []string `yaml:"exclude" desc:"IDs of included rules that should be dropped"`
+	// Field overrides for rules (including included ones) identified via ID
+	Overrides  map[string]RuleOverride `yaml:"overrides" desc:"Field overrides for rules identified via ID"`
 	tmpDirPath string
 	ids        map[string]int
 	targetList map[string]bool
 }
 
 type Rule struct {
-	Category        string `yaml:"category"`
-	Instruction     string `yaml:"instruction"`
-	Description     string `yaml:"description"`
-	LongDescription string `yaml:"long_description"`
-	Id              string `yaml:"id"`
-	Target          string `yaml:"target"`
-	Runner          runner.Runner
-	FixInstruction  string `yaml:"fix_instruction"`
+	Category        string   `yaml:"category" desc:"Category of the rule"`
+	Instruction     string   `yaml:"instruction" desc:"Python code that asserts the rule or reports findings using report"`
+	Description     string   `yaml:"description" desc:"Short description of the rule"`
+	LongDescription string   `yaml:"long_description" desc:"Detailed description shown in the docs"`
+	Id              string   `yaml:"id" desc:"Unique identifier of the rule"`
+	Target          string   `yaml:"target" desc:"Highest util the rule needs. Determines the inputs that are needed"`
+	Severity        string   `yaml:"severity" desc:"Severity of a violation of the rule (default: medium)"`
+	Tags            []string `yaml:"tags" desc:"Tags for selecting rules using --only and --skip"`
+	// Available as `params` in the instruction and fix instruction
+	Params         map[string]any `yaml:"params" desc:"Parameters available as params in the instruction and fix instruction"`
+	Runner         runner.Runner  `yaml:"-"`
+	FixInstruction string         `yaml:"fix_instruction" desc:"Python code that fixes a violation using fix_util"`
+	// Test cases run by `rules test`
+	Tests []RuleTest `yaml:"tests" desc:"Test cases with inline Dockerfiles, run using rules test"`
+	// Where the rule was defined, set when loading the ruleset
+	Source RuleSource `yaml:"-"`
+}
+
+type RuleSource struct {
+	// File path or <repository>.git!<path> of the defining ruleset
+	Location    string
+	RuleSetName string
+}
+
+func (s RuleSource) String() string {
+	if len(s.RuleSetName) == 0 {
+		return s.Location
+	}
+	return fmt.Sprintf("%s (%s)", s.RuleSetName, s.Location)
+}
+
+// Fields of a rule that can be changed by an including ruleset
+// Empty fields are not overridden
+type RuleOverride struct {
+	Description     string   `yaml:"description" desc:"Replaces the description"`
+	LongDescription string   `yaml:"long_description" desc:"Replaces the long description"`
+	Severity        string   `yaml:"severity" desc:"Replaces the severity"`
+	FixInstruction  string   `yaml:"fix_instruction" desc:"Replaces the fix instruction"`
+	Tags            []string `yaml:"tags" desc:"Replaces the tags"`
 }
 
 func (r *Rule) AddRunner() error {
@@ -60,12 +124,24 @@ func (r *Rule) GetUtilLevel() int {
 	}
 }
 
-func (r *Rule) Validate(ociTarPath, dockerFilepath, dockerTarPath string) (bool, ViolationInfo) {
-	err := r.Runner.Run(runner.TemplateData{DockerfilePath: dockerFilepath, OciImage: ociTarPath, DockerImage: dockerTarPath}, r.Instruction, r.GetUtilLevel())
+func (r *Rule) Validate(ociTarPath, dockerFilepath, dockerTarPath string) (Outcome, ViolationInfo) {
+	result, err := r.Runner.Run(runner.TemplateData{DockerfilePath: dockerFilepath, OciImage: ociTarPath, DockerImage: dockerTarPath, Params: r.Params}, r.Instruction, r.GetUtilLevel())
 	if err != nil {
-		return false, ViolationInfo{Details: err.Error()}
+		details := err.Error()
+		if result.Exception != nil && len(result.Exception.Message) > 0 {
+			details = result.Exception.Message
+		}
+		info := ViolationInfo{Details: details, Locations: result.Locations, Reports: result.Reports, Exception: result.Exception, Timings: result.Timings}
+		if result.Exception == nil || result.Exception.Type != runner.AssertionExceptionType {
+			return OutcomeErrored, info
+		}
+		return OutcomeViolated, info
+	}
+	// Reports fail the rule even if every assertion held
+	if len(result.Reports) > 0 {
+		return OutcomeViolated, ViolationInfo{Details: fmt.Sprintf("Rule reported %d findings", len(result.Reports)), Locations: result.Locations, Reports: result.Reports, Timings: result.Timings}
 	}
-	return true, ViolationInfo{}
+	return OutcomePassed, ViolationInfo{Timings: result.Timings}
 }
 
 // Cleanup if this was loaded from git
@@ -82,6 +158,13 @@ func (rs *RuleSet) updateIdList() {
 	}
 }
 
+func (rs *RuleSet) updateTargetList() {
+	rs.targetList = make(map[string]bool)
+	for _, rule := range rs.Rules {
+		rs.targetList[rule.Target] = true
+	}
+}
+
 // Considers currently target allowlist in config
 func (rs *RuleSet) GetHighestTarget() string {
 	for _, target := range []string{"os", "fs"} {
@@ -94,6 +177,7 @@ func (rs *RuleSet) GetHighestTarget() string {
 
 // Take all rules fromt he weaker set where the current set does not have a rule yet
 // identified via ID
+// Rules excluded by the current set are dropped
 func (rs *RuleSet) Swallow(weakerSet RuleSet) {
 	if len(rs.Rules) != len(rs.ids) {
 		rs.updateIdList()
@@ -102,6 +186,10 @@ func (rs *RuleSet) Swallow(weakerSet RuleSet) {
 		rs.targetList = make(map[string]bool)
 	}
 	for _, rule := range weakerSet.Rules {
+		if slices.Contains(rs.Exclude, rule.Id) {
+			log.Debug().Str("id", rule.Id).Str("ruleset", rs.Name).Msg("Dropping excluded rule")
+			continue
+		}
 		if _, ok := rs.ids[rule.Id]; !ok {
 			rs.Rules = append(rs.Rules, rule)
 			rs.targetList[rule.Target] = true
@@ -109,6 +197,60 @@ func (rs *RuleSet) Swallow(weakerSet RuleSet) {
 	}
 }
 
+// Apply the field overrides of the set onto its rules
+func (rs *RuleSet) applyOverrides() error {
+	if len(rs.Rules) != len(rs.ids) {
+		rs.updateIdList()
+	}
+	for id, override := range rs.Overrides {
+		index, ok := rs.ids[id]
+		if !ok {
+			log.Warn().Str("id", id).Str("ruleset", rs.Name).Msg("Override for a rule that is not part of the ruleset")
+			continue
+		}
+		rule := rs.Rules[index]
+		if len(override.Description) > 0 {
+			rule.Description = override.Description
+		}
+		if len(override.LongDescription) > 0 {
+			rule.LongDescription = override.LongDescription
+		}
+		if len(override.Severity) > 0 {
+			rule.Severity = override.Severity
+		}
+		if len(override.FixInstruction) > 0 {
+			rule.FixInstruction = override.FixInstruction
+		}
+		if override.Tags != nil {
+			rule.Tags = override.Tags
+		}
+		if err := rule.Verify(); err != nil {
+			return fmt.Errorf("Override for %s: %s", id, err.Error())
+		}
+	}
+	return nil
+}
+
+// Apply the parameter overrides of the set onto its rules
+// Overrides replace the default value of the parameter, parameters that are not overridden are kept
+func (rs *RuleSet) applyParamOverrides() {
+	if len(rs.Rules) != len(rs.ids) {
+		rs.updateIdList()
+	}
+	for id, params := range rs.Params {
+		index, ok := rs.ids[id]
+		if !ok {
+			log.Warn().Str("id", id).Str("ruleset", rs.Name).Msg("Params were overridden for a rule that is not part of the ruleset")
+			continue
+		}
+		rule := rs.Rules[index]
+		merged := make(map[string]any, len(rule.Params)+len(params))
+		maps.Copy(merged, rule.Params)
+		maps.Copy(merged, params)
+		rule.Params = merged
+	}
+}
+
 func (r *Rule) Verify() error {
 	// TODO: Add instruction verify as soon as helper format is clear
 	if len(r.Id) == 0 {
@@ -122,6 +264,18 @@ func (r *Rule) Verify() error {
 	if err := isInAllowed(r.Target, allowedTargets); err != nil {
 		return fmt.Errorf("Target: %s", err.Error())
 	}
+	if len(r.Severity) == 0 {
+		r.Severity = DefaultSeverity
+	}
+	r.Severity = strings.ToLower(r.Severity)
+	if err := isInAllowed(r.Severity, allowedSeverities); err != nil {
+		return fmt.Errorf("Severity: %s", err.Error())
+	}
+	for i := range r.Tests {
+		if err := r.Tests[i].verify(r); err != nil {
+			return fmt.Errorf("Test %s: %s", r.Tests[i].GetName(i), err.Error())
+		}
+	}
 	return nil
 }
 
@@ -129,10 +283,25 @@ func (r *Rule) PerformFix() error {
 	if r.FixInstruction == "" {
 		return errors.New("No fixinstruction present")
 	}
-	r.Runner.RunFix(r.FixInstruction)
+	r.Runner.RunFix(r.FixInstruction, r.Params)
 	return nil
 }
 
+// Check if the given value is a known severity
+func VerifySeverity(severity string) error {
+	return isInAllowed(strings.ToLower(severity), allowedSeverities)
+}
+
+// Position of the severity in the ordered severity list (info being the lowest)
+// Unknown severities are treated as the default severity
+func SeverityRank(severity string) int {
+	index := slices.Index(allowedSeverities, strings.ToLower(severity))
+	if index == -1 {
+		return slices.Index(allowedSeverities, DefaultSeverity)
+	}
+	return index
+}
+
 func isInAllowed(value string, allowList []string) error {
 	if !slices.Contains(allowList, value) {
 		return errors.New(fmt.Sprintf("Invalid value %s (Allowed: %+q)", value, allowList))
@@ -126,3 +126,36 @@ func TestSwallowFullOverlap(t *testing.T) {
 		}
 	}
 }
+
+func TestSwallowExclude(t *testing.T) {
+	strongSet := rules.RuleSet{
+		Name:    "strong",
+		Exclude: []string{"unique_3"},
+		Rules: []*rules.Rule{
+			{
+				Id: "unique_1",
+			},
+		},
+	}
+	weakSet := rules.RuleSet{
+		Name: "weak",
+		Rules: []*rules.Rule{
+			{
+				Id: "unique_2",
+			},
+			{
+				Id: "unique_3",
+			},
+		},
+	}
+	strongSet.Swallow(weakSet)
+
+	if len(strongSet.Rules) != 2 {
+		t.Errorf("rule count mismatch: Expected 2 Got %d", len(strongSet.Rules))
+	}
+	for i := range 2 {
+		if strongSet.Rules[i].Id != fmt.Sprintf("unique_%d", i+1) {
+			t.Errorf("rule mismatch: Expected %s Got %s", fmt.Sprintf("unique_%d", i+1), strongSet.Rules[i].Id)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package commandutil
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 	"reflect"
 	"slices"
 	"strconv"
@@ -14,6 +16,8 @@ import (
 
 type CommandUtils struct {
 	astRoot *ast.StageNode
+	// Source lines, used to find the location of nodes
+	lines []string
 }
 
 // Setup function used for instantiating util struct
@@ -23,7 +27,11 @@ func SetupFromPath(DockerfilePath string) CommandUtils {
 	if err != nil {
 		panic(err)
 	}
-	return CommandUtils{astRoot: root}
+	data, err := os.ReadFile(DockerfilePath)
+	if err != nil {
+		panic(err)
+	}
+	return CommandUtils{astRoot: root, lines: strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")}
 }
 
 func SetupFromContent(DockerfileContent []string) CommandUtils {
@@ -31,7 +39,37 @@ func SetupFromContent(DockerfileContent []string) CommandUtils {
 	if err != nil {
 		panic(err)
 	}
-	return CommandUtils{astRoot: root}
+	return CommandUtils{astRoot: root, lines: DockerfileContent}
+}
+
+// Location (lines, stage and source text) of the node as JSON
+// Returns an empty string if the node cannot be located
+func (cu *CommandUtils) GetNodeLocation(node *ast.Node) string {
+	if node == nil {
+		return ""
+	}
+	return cu.locate(*node)
+}
+
+func (cu *CommandUtils) GetStageNodeLocation(sn *ast.StageNode) string {
+	if sn == nil {
+		return ""
+	}
+	return cu.locate(sn)
+}
+
+func (cu *CommandUtils) locate(node ast.Node) string {
+	location, err := container.LocateNode(cu.astRoot, cu.lines, node)
+	if err != nil {
+		return ""
+	}
+	var data strings.Builder
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	if err = encoder.Encode(location); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(data.String())
 }
 
 func (cu *CommandUtils) GetStageNodeAt(index int) *ast.StageNode {
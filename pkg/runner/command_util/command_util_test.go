@@ -365,3 +365,19 @@ func TestGetNodeInstructionString(t *testing.T) {
 		}
 	}
 }
+
+func TestGetNodeLocation(t *testing.T) {
+	cu := commandutil.SetupFromContent(sampleDockerfile)
+	nodes := cu.GetEveryNodeOfInstruction("RUN")
+	expected := `{"start_line":6,"end_line":9,"stage_index":0,"stage_name":"build","instruction":"RUN apt update && apt install -y python3-pip && \\\n(python3 -m pip install pybindgen --break-system-packages && \\\ngo install golang.org/x/tools/cmd/goimports@latest) && \\\ngo install github.com/go-python/gopy@latest"}`
+	if actual := cu.GetNodeLocation(&nodes[0]); actual != expected {
+		t.Errorf("Location mismatch: Expected %s Got %s", expected, actual)
+	}
+	expected = `{"start_line":16,"end_line":16,"stage_index":1,"stage_name":"runtime","instruction":"FROM python:3.10-bookworm AS runtime"}`
+	if actual := cu.GetStageNodeLocation(cu.GetStageNodeAt(1)); actual != expected {
+		t.Errorf("Location mismatch: Expected %s Got %s", expected, actual)
+	}
+	if actual := cu.GetNodeLocation(nil); actual != "" {
+		t.Errorf("Location mismatch: Expected empty Got %s", actual)
+	}
+}
@@ -2,10 +2,15 @@ package runner
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"regexp"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/rs/zerolog/log"
 )
@@ -20,13 +25,217 @@ type TemplateData struct {
 	DockerfilePath string
 	OciImage       string
 	DockerImage    string
+	// Rule parameters, exposed as `params` in the python namespace
+	Params map[string]any
 }
 
-func (r *PythonRunner) RunFix(command string) {
+// Name of the environment variable the rule parameters are passed through
+const paramsEnvVar = "WHALE_WATCHER_PARAMS"
+
+// Preamble that makes the rule parameters available as dict
+const paramsImport = "import json as _ww_json; import os as _ww_os; params = _ww_json.loads(_ww_os.environ.get('" + paramsEnvVar + "', '{}'));"
+
+// Name of the environment variable holding the path of the side channel file
+const sideChannelEnvVar = "WHALE_WATCHER_SIDE_CHANNEL"
+
+// Name of the environment variable holding the number of preamble lines before the instruction
+// Used to point tracebacks to the line in the instruction rather than the generated script
+const lineOffsetEnvVar = "WHALE_WATCHER_LINE_OFFSET"
+
+// Defines set_location(node) which attaches the location of an ast node to the violation
+// and report(message, node=None, severity=None) which adds a finding, every finding becomes its own violation
+// Uncaught exceptions (including failed assertions) are recorded with their message and traceback
+// Everything is written to the side channel file as one JSON object per line
+const sideChannelImport = `
+import sys as _ww_sys
+import traceback as _ww_traceback
+def _ww_write_side_channel(record):
+    with open(_ww_os.environ['` + sideChannelEnvVar + `'], 'a') as _ww_file:
+        _ww_file.write(_ww_json.dumps(record) + '\n')
+def _ww_get_location(node):
+    if node is None:
+        return None
+    if type(node).__name__.endswith('StageNode'):
+        _ww_location = command_util.get_stage_node_location(node)
+    else:
+        _ww_location = command_util.get_node_location(node)
+    return _ww_json.loads(_ww_location) if _ww_location else None
+def set_location(node):
+    _ww_location = _ww_get_location(node)
+    if _ww_location:
+        _ww_write_side_channel({'kind': 'location', 'location': _ww_location})
+def report(message, node=None, severity=None):
+    _ww_write_side_channel({'kind': 'report', 'message': str(message), 'severity': severity or '', 'location': _ww_get_location(node)})
+def _ww_excepthook(exc_type, exc, tb):
+    _ww_offset = int(_ww_os.environ.get('` + lineOffsetEnvVar + `', '-1'))
+    _ww_frames = []
+    for _ww_frame in _ww_traceback.extract_tb(tb):
+        if _ww_offset >= 0 and _ww_frame.filename == '<string>' and _ww_frame.lineno > _ww_offset:
+            _ww_frames.append(('<instruction>', _ww_frame.lineno - _ww_offset, _ww_frame.name, _ww_frame.line))
+        else:
+            _ww_frames.append((_ww_frame.filename, _ww_frame.lineno, _ww_frame.name, _ww_frame.line))
+    _ww_trace = 'Traceback (most recent call last):\n' + ''.join(_ww_traceback.format_list(_ww_frames)) + ''.join(_ww_traceback.format_exception_only(exc_type, exc))
+    try:
+        _ww_write_side_channel({'kind': 'exception', 'type': exc_type.__name__, 'message': str(exc), 'traceback': _ww_trace})
+    finally:
+        _ww_sys.__excepthook__(exc_type, exc, tb)
+_ww_sys.excepthook = _ww_excepthook
+`
+
+// Starts the timer of the util setup, has to precede the util imports
+const setupTimerImport = "import time as _ww_time; _ww_setup_started = _ww_time.perf_counter();"
+
+// Records the time it took to import and set up the utils, i.e. to load the Dockerfile and image
+// Has to follow the side channel import
+const setupTimerReport = "_ww_write_side_channel({'kind': 'setup', 'seconds': _ww_time.perf_counter() - _ww_setup_started})\n"
+
+const (
+	sideChannelKindLocation  = "location"
+	sideChannelKindReport    = "report"
+	sideChannelKindException = "exception"
+	sideChannelKindSetup     = "setup"
+)
+
+// Data passed back by the rule through the side channel
+type RunResult struct {
+	Locations []Location
+	Reports   []Report
+	// Set if the rule raised, nil if it ran through
+	Exception *Exception
+	Timings   Timings
+}
+
+// Time spent preparing a rule run, the rest of the run is spent in the instruction and the interpreter
+type Timings struct {
+	// Copying the Dockerfile, image tarballs and utils to the working directory
+	Populate time.Duration
+	// Importing the utils, which loads the Dockerfile and the image
+	Setup time.Duration
+}
+
+// Location of an instruction in the Dockerfile as attached by the rule
+type Location struct {
+	StartLine   int    `json:"start_line"`
+	EndLine     int    `json:"end_line"`
+	StageIndex  int    `json:"stage_index"`
+	StageName   string `json:"stage_name"`
+	Instruction string `json:"instruction"`
+}
+
+// Finding added by the rule using report()
+type Report struct {
+	Message string `json:"message"`
+	// Empty if the severity of the rule applies
+	Severity string `json:"severity"`
+	// Nil if no node was passed
+	Location *Location `json:"location"`
+}
+
+// Uncaught exception of the rule, a failed assertion is an AssertionError
+type Exception struct {
+	Type string `json:"type"`
+	// Message of the exception, for assertions the explanation after the comma
+	Message   string `json:"message"`
+	Traceback string `json:"traceback"`
+}
+
+type sideChannelRecord struct {
+	Kind      string    `json:"kind"`
+	Message   string    `json:"message"`
+	Severity  string    `json:"severity"`
+	Location  *Location `json:"location"`
+	Type      string    `json:"type"`
+	Traceback string    `json:"traceback"`
+	Seconds   float64   `json:"seconds"`
+}
+
+// Create the side channel file for a run, the caller has to remove it
+func createSideChannel(dir string) (string, error) {
+	f, err := os.CreateTemp(dir, "side-channel-*.jsonl")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return f.Name(), nil
+}
+
+func readSideChannel(path string) (RunResult, error) {
+	result := RunResult{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return result, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		record := sideChannelRecord{}
+		if err = json.Unmarshal([]byte(line), &record); err != nil {
+			return result, fmt.Errorf("Invalid record in side channel: %s", err.Error())
+		}
+		switch record.Kind {
+		case sideChannelKindLocation:
+			if record.Location == nil {
+				return result, errors.New("Invalid record in side channel: location record without location")
+			}
+			result.Locations = append(result.Locations, *record.Location)
+		case sideChannelKindReport:
+			result.Reports = append(result.Reports, Report{Message: record.Message, Severity: record.Severity, Location: record.Location})
+		case sideChannelKindException:
+			result.Exception = &Exception{Type: record.Type, Message: record.Message, Traceback: record.Traceback}
+		case sideChannelKindSetup:
+			result.Timings.Setup = time.Duration(record.Seconds * float64(time.Second))
+		default:
+			return result, fmt.Errorf("Invalid record in side channel: unknown kind %s", record.Kind)
+		}
+	}
+	return result, nil
+}
+
+// Exception type of a failed assertion, every other exception means the rule could not be evaluated
+const AssertionExceptionType = "AssertionError"
+
+var exceptionLinePattern = regexp.MustCompile(`^([A-Za-z_][\w.]*(?:Error|Exception|Exit|Interrupt))(?::\s*(.*))?$`)
+
+// Fallback for exceptions raised before the excepthook is installed (e.g. syntax errors or failing util setup)
+// The last line of a python traceback is the exception type followed by the message
+func parseException(stderr string) *Exception {
+	stderr = strings.TrimSpace(stderr)
+	if len(stderr) == 0 {
+		return nil
+	}
+	lines := strings.Split(stderr, "\n")
+	last := strings.TrimSpace(lines[len(lines)-1])
+	exception := &Exception{Message: last, Traceback: stderr}
+	if match := exceptionLinePattern.FindStringSubmatch(last); match != nil {
+		exception.Type = match[1]
+		exception.Message = match[2]
+	}
+	return exception
+}
+
+func paramsToEnv(params map[string]any) (string, error) {
+	if params == nil {
+		params = map[string]any{}
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return "", fmt.Errorf("Could not serialize rule params: %s", err.Error())
+	}
+	return fmt.Sprintf("%s=%s", paramsEnvVar, data), nil
+}
+
+func (r *PythonRunner) RunFix(command string, params map[string]any) {
 	log.Info().Msg("Running fix")
 	w := GetReferencingWorkingDirectoryInstance()
 	defer w.Free()
-	importTemplate := "from command_util_build import commandutil; command_util = commandutil.setup_from_path('{{ .DockerfilePath }}');from fix_util_build import fixutil; fix_util = fixutil.setup_from_path('{{ .DockerfilePath }}');"
+	importTemplate := paramsImport + "from command_util_build import commandutil; command_util = commandutil.setup_from_path('{{ .DockerfilePath }}');from fix_util_build import fixutil; fix_util = fixutil.setup_from_path('{{ .DockerfilePath }}');"
+
+	paramsEnv, err := paramsToEnv(params)
+	if err != nil {
+		log.Error().Err(err).Msg("Not running fix")
+		return
+	}
 
 	contextData := TemplateData{
 		DockerfilePath: "./Dockerfile",
@@ -42,6 +251,8 @@ func (r *PythonRunner) RunFix(command string) {
 	command = buffer.String() + "\n" + command
 	cmd := exec.Command(r.exec, "-c", command)
 	cmd.Dir = r.workingDirectory.tmpDirPath
+	// fixes run with the full environment, only the params are added
+	cmd.Env = append(os.Environ(), paramsEnv)
 
 	var errorOutput bytes.Buffer
 	var stdOutput bytes.Buffer
@@ -49,7 +260,7 @@ func (r *PythonRunner) RunFix(command string) {
 	cmd.Stdout = &stdOutput
 	cmd.Stderr = &errorOutput
 
-	err := cmd.Run()
+	err = cmd.Run()
 	if err != nil {
 		log.Error().Err(err).Str("stderr", errorOutput.String()).Str("stdout", stdOutput.String()).Send()
 		// signal aborted indicates an issue with the gopy build result, advancing is useless
@@ -59,11 +270,24 @@ func (r *PythonRunner) RunFix(command string) {
 	}
 }
 
-func (r *PythonRunner) Run(contextData TemplateData, command string, util_level int) error {
+func (r *PythonRunner) Run(contextData TemplateData, command string, util_level int) (RunResult, error) {
 
 	defer r.workingDirectory.Free()
 
+	paramsEnv, err := paramsToEnv(contextData.Params)
+	if err != nil {
+		return RunResult{}, err
+	}
+
+	populateStarted := time.Now()
 	r.workingDirectory.Populate(contextData.DockerfilePath, contextData.OciImage, contextData.DockerImage, util_level)
+	populateDuration := time.Since(populateStarted)
+
+	sideChannel, err := createSideChannel(r.workingDirectory.tmpDirPath)
+	if err != nil {
+		return RunResult{}, err
+	}
+	defer os.Remove(sideChannel)
 
 	contextData.DockerfilePath = "./Dockerfile"
 	contextData.OciImage = "./out.tar"
@@ -76,7 +300,7 @@ func (r *PythonRunner) Run(contextData TemplateData, command string, util_level
 	cmd := exec.Command(r.exec, "-c", command)
 	cmd.Dir = r.workingDirectory.tmpDirPath
 	// only log panic
-	cmd.Env = append(cmd.Env, "WHALE_WATCHER_LOG_LEVEL=5")
+	cmd.Env = append(cmd.Env, "WHALE_WATCHER_LOG_LEVEL=5", paramsEnv, fmt.Sprintf("%s=%s", sideChannelEnvVar, sideChannel), fmt.Sprintf("%s=%d", lineOffsetEnvVar, strings.Count(buffer.String(), "\n")+1))
 
 	var errorOutput bytes.Buffer
 	var stdOutput bytes.Buffer
@@ -84,7 +308,15 @@ func (r *PythonRunner) Run(contextData TemplateData, command string, util_level
 	cmd.Stdout = &stdOutput
 	cmd.Stderr = &errorOutput
 
-	err := cmd.Run()
+	err = cmd.Run()
+	result, sideChannelErr := readSideChannel(sideChannel)
+	if sideChannelErr != nil {
+		log.Warn().Err(sideChannelErr).Msg("Could not read side channel of rule")
+	}
+	result.Timings.Populate = populateDuration
+	if err != nil && result.Exception == nil {
+		result.Exception = parseException(errorOutput.String())
+	}
 	if err != nil {
 		// If it is just an assertion error we dont need to throw it
 		if strings.Contains(err.Error(), "AssertionError") {
@@ -92,12 +324,41 @@ func (r *PythonRunner) Run(contextData TemplateData, command string, util_level
 		} else {
 			log.Debug().Err(err).Str("stderr", errorOutput.String()).Str("stdout", stdOutput.String()).Send()
 		}
-		return err
+		return result, err
 	}
 
-	return nil
+	return result, nil
 }
 
 func (r PythonRunner) ToString() string {
 	return fmt.Sprintf("Exec: %s with preamble %s", r.exec, r.utilImport.Root.String())
 }
+
+const syntaxCheckScript = `import sys
+try:
+    compile(sys.stdin.read(), '<instruction>', 'exec')
+except SyntaxError as e:
+    print(f"line {e.lineno}: {type(e).__name__}: {e.msg}")
+    sys.exit(1)
+`
+
+// Compile the python code without executing it to detect syntax errors
+func CheckSyntax(code string) error {
+	cmd := exec.Command("python3", "-c", syntaxCheckScript)
+	cmd.Stdin = strings.NewReader(code)
+
+	var errorOutput bytes.Buffer
+	var stdOutput bytes.Buffer
+
+	cmd.Stdout = &stdOutput
+	cmd.Stderr = &errorOutput
+
+	err := cmd.Run()
+	if err != nil {
+		if stdOutput.Len() > 0 {
+			return fmt.Errorf("%s", strings.TrimSpace(stdOutput.String()))
+		}
+		return fmt.Errorf("Could not check syntax: %s %s", err.Error(), strings.TrimSpace(errorOutput.String()))
+	}
+	return nil
+}
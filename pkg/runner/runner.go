@@ -6,8 +6,8 @@ import (
 )
 
 type Runner interface {
-	Run(TemplateData, string, int) error
-	RunFix(command string)
+	Run(TemplateData, string, int) (RunResult, error)
+	RunFix(command string, params map[string]any)
 	ToString() string
 }
 
@@ -17,12 +17,22 @@ var targetScore = map[string]int{
 	"os":      2,
 }
 
+// Score of the target, targets with a higher score import more utils
+// Unknown targets have a score of -1
+func GetTargetScore(target string) int {
+	score, ok := targetScore[target]
+	if !ok {
+		return -1
+	}
+	return score
+}
+
 func NewPythonRunner(target string) (Runner, error) {
 	runner := &PythonRunner{
 		exec:             "python3",
 		workingDirectory: GetReferencingWorkingDirectoryInstance(),
 	}
-	importTemplate := ""
+	importTemplate := setupTimerImport + paramsImport
 	score, ok := targetScore[target]
 	if !ok {
 		return nil, fmt.Errorf("Unsupported target: %s! Supported targets are: command, fs, os", target)
@@ -38,6 +48,8 @@ func NewPythonRunner(target string) (Runner, error) {
 		importTemplate += "from os_util_build import osutil; os_util = osutil.setup('{{ .DockerImage }}');"
 	}
 
+	importTemplate += sideChannelImport + setupTimerReport
+
 	var err error
 	runner.utilImport, err = template.New("").Parse(importTemplate)
 	if err != nil {
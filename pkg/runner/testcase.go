@@ -0,0 +1,98 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Name of the environment variable the Dockerfile of a rule test is passed through
+const testDockerfileEnvVar = "WHALE_WATCHER_TEST_DOCKERFILE"
+
+// Name of the file the fixed Dockerfile of a rule test is written to
+const testFixedDockerfile = "Dockerfile.fixed"
+
+// Preamble for rule tests, the utils are set up from the Dockerfile content rather than a path
+// There is no image for rule tests so only command_util is available
+const testCommandImport = paramsImport + "_ww_lines = _ww_json.loads(_ww_os.environ['" + testDockerfileEnvVar + "']);" +
+	"from command_util_build import commandutil, go as _ww_command_go; command_util = commandutil.setup_from_content(_ww_command_go.Slice_string(_ww_lines));" +
+	sideChannelImport
+
+const testFixImport = testCommandImport +
+	"\nfrom fix_util_build import fixutil, go as _ww_fix_go; fix_util = fixutil.setup_from_content(_ww_fix_go.Slice_string(_ww_lines));"
+
+// fix_util does not write the result when set up from content so it is written for comparison here
+const testFixExport = "\nwith open('./" + testFixedDockerfile + "', 'w') as _ww_fixed: _ww_fixed.write('\\n'.join(fix_util.get_reconstruct()))"
+
+// Run the instruction against the given Dockerfile content
+// Returns false if the instruction failed its assertion or reported a finding and an error if it could not be run
+func RunTest(dockerfile []string, instruction string, params map[string]any) (bool, error) {
+	w := GetReferencingWorkingDirectoryInstance()
+	defer w.Free()
+	result, stderr, err := runTestCommand(w, testCommandImport+"\n"+instruction, dockerfile, params)
+	if err != nil {
+		// Classified the same way as validate does it, only failed assertions are violations
+		exception := result.Exception
+		if exception == nil {
+			exception = parseException(stderr)
+		}
+		if exception != nil && exception.Type == AssertionExceptionType {
+			return false, nil
+		}
+		return false, fmt.Errorf("%s: %s", err.Error(), strings.TrimSpace(stderr))
+	}
+	return len(result.Reports) == 0, nil
+}
+
+// Run the fix instruction against the given Dockerfile content and return the fixed Dockerfile
+func RunFixTest(dockerfile []string, fixInstruction string, params map[string]any) ([]string, error) {
+	w := GetReferencingWorkingDirectoryInstance()
+	defer w.Free()
+	os.Remove(w.GetAbsolutePath(testFixedDockerfile))
+	_, stderr, err := runTestCommand(w, testFixImport+"\n"+fixInstruction+testFixExport, dockerfile, params)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", err.Error(), strings.TrimSpace(stderr))
+	}
+	data, err := os.ReadFile(w.GetAbsolutePath(testFixedDockerfile))
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(string(data), "\n"), nil
+}
+
+func runTestCommand(w *RunnerWorkingDirectory, command string, dockerfile []string, params map[string]any) (RunResult, string, error) {
+	if err := w.populateForTest(); err != nil {
+		return RunResult{}, "", err
+	}
+	sideChannel, err := createSideChannel(w.tmpDirPath)
+	if err != nil {
+		return RunResult{}, "", err
+	}
+	defer os.Remove(sideChannel)
+	paramsEnv, err := paramsToEnv(params)
+	if err != nil {
+		return RunResult{}, "", err
+	}
+	lines, err := json.Marshal(dockerfile)
+	if err != nil {
+		return RunResult{}, "", err
+	}
+
+	cmd := exec.Command("python3", "-c", command)
+	cmd.Dir = w.tmpDirPath
+	// only log panic
+	cmd.Env = append(cmd.Env, "WHALE_WATCHER_LOG_LEVEL=5", paramsEnv, fmt.Sprintf("%s=%s", testDockerfileEnvVar, lines), fmt.Sprintf("%s=%s", sideChannelEnvVar, sideChannel))
+
+	var errorOutput bytes.Buffer
+	cmd.Stderr = &errorOutput
+
+	err = cmd.Run()
+	result, sideChannelErr := readSideChannel(sideChannel)
+	if sideChannelErr != nil && err == nil {
+		return result, errorOutput.String(), sideChannelErr
+	}
+	return result, errorOutput.String(), err
+}
@@ -38,6 +38,7 @@ type RunnerWorkingDirectory struct {
 	refCount           int
 	isPopulated        bool
 	current_util_level int
+	hasTestUtils       bool
 }
 
 var instance *RunnerWorkingDirectory
@@ -158,13 +159,31 @@ func (rwd *RunnerWorkingDirectory) extractUtils(utilLevel int) error {
 	}
 
 	// no fix utils needed if we are running again or in nofix
-	if viper.GetBool("nofix") || rwd.isPopulated {
+	if viper.GetBool("no_fix") || rwd.isPopulated {
 		return nil
 	}
 
 	return unpackFsToDir(fixutil, rwd.tmpDirPath)
 }
 
+// Rule tests only work on the Dockerfile content, so only command_util and fix_util are needed
+func (rwd *RunnerWorkingDirectory) populateForTest() error {
+	if rwd.hasTestUtils {
+		return nil
+	}
+	if rwd.current_util_level < COMMAND_UTIL_LEVEL {
+		if err := unpackFsToDir(cmdutil, rwd.tmpDirPath); err != nil {
+			return err
+		}
+		rwd.current_util_level = COMMAND_UTIL_LEVEL
+	}
+	if err := unpackFsToDir(fixutil, rwd.tmpDirPath); err != nil {
+		return err
+	}
+	rwd.hasTestUtils = true
+	return nil
+}
+
 func newRunnerWorkingDirectory() (*RunnerWorkingDirectory, error) {
 	dirPath, err := getTmpDir()
 	if err != nil {
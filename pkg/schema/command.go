@@ -0,0 +1,51 @@
+package schema
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func NewCommand() *cobra.Command {
+	var outputPath string
+
+	var cmd = &cobra.Command{
+		Use:   "schema [flags] <ruleset|config>",
+		Short: "Print the JSON schema of the ruleset or config format",
+		Long: `Print the JSON schema of the ruleset or config format. The schemas are generated from the types used for loading and can be used for editor integration.
+
+Expected arguments:  <ruleset|config>
+		`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("Schema only takes exactly one argument, the schema name %+q (Got: '%s')", GetSchemaNames(), strings.Join(args, " "))
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var output io.Writer = os.Stdout
+			if outputPath != "" {
+				f, err := os.Create(outputPath)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				output = f
+			}
+			return WriteSchema(output, args[0])
+		},
+	}
+
+	schemaFlags := pflag.NewFlagSet("Schema Options", pflag.ExitOnError)
+
+	schemaFlags.StringVarP(&outputPath, "output", "o", "", "Write the schema to this file instead of stdout")
+	schemaFlags.SetAnnotation("output", "group", []string{schemaFlags.Name()})
+
+	cmd.Flags().AddFlagSet(schemaFlags)
+
+	return cmd
+}
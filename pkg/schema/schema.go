@@ -0,0 +1,31 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/config"
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/rules"
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/util"
+)
+
+var schemas = map[string]func() *util.JSONSchema{
+	"ruleset": rules.GetRulesetSchema,
+	"config":  config.GetConfigSchema,
+}
+
+func GetSchemaNames() []string {
+	return []string{"ruleset", "config"}
+}
+
+func WriteSchema(w io.Writer, name string) error {
+	generate, ok := schemas[name]
+	if !ok {
+		return fmt.Errorf("Unknown schema: %s (Available: %+q)", name, GetSchemaNames())
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(generate())
+}
@@ -0,0 +1,34 @@
+package schema_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/schema"
+)
+
+// The published schemas have to be regenerated using make schemas if the types change
+func TestPublishedSchemasUpToDate(t *testing.T) {
+	for _, name := range schema.GetSchemaNames() {
+		var generated bytes.Buffer
+		if err := schema.WriteSchema(&generated, name); err != nil {
+			t.Fatal(err)
+		}
+		published, err := os.ReadFile(filepath.Join("..", "..", "schemas", name+".schema.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(generated.Bytes(), published) {
+			t.Errorf("Published schema %s is outdated, run make schemas", name)
+		}
+	}
+}
+
+func TestUnknownSchema(t *testing.T) {
+	var generated bytes.Buffer
+	if err := schema.WriteSchema(&generated, "unknown"); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
@@ -0,0 +1,136 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Number of unchanged lines shown around each change
+const diffContext = 3
+
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// Line based diff in the unified format, empty if both contents are equal
+func UnifiedDiff(oldName, newName, oldContent, newContent string) string {
+	if oldContent == newContent {
+		return ""
+	}
+	lines := diffLines(splitLines(oldContent), splitLines(newContent))
+
+	var res strings.Builder
+	fmt.Fprintf(&res, "--- %s\n+++ %s\n", oldName, newName)
+	// Position of the current line in the old and new content, 0 based
+	oldLine, newLine := 0, 0
+	for start := 0; start < len(lines); {
+		// Skip unchanged lines that are not context of a change
+		next := start
+		for next < len(lines) && lines[next].op == ' ' {
+			next++
+		}
+		if next == len(lines) {
+			break
+		}
+		hunkStart := max(start, next-diffContext)
+		oldLine += hunkStart - start
+		newLine += hunkStart - start
+
+		// Extend the hunk until the unchanged lines separate it from the next change
+		end := next
+		for end < len(lines) {
+			if lines[end].op != ' ' {
+				end++
+				continue
+			}
+			unchanged := end
+			for unchanged < len(lines) && lines[unchanged].op == ' ' {
+				unchanged++
+			}
+			if unchanged == len(lines) || unchanged-end > 2*diffContext {
+				end = min(end+diffContext, len(lines))
+				break
+			}
+			end = unchanged
+		}
+
+		oldCount, newCount := 0, 0
+		for _, line := range lines[hunkStart:end] {
+			if line.op != '+' {
+				oldCount++
+			}
+			if line.op != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&res, "@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))
+		for _, line := range lines[hunkStart:end] {
+			res.WriteByte(line.op)
+			res.WriteString(line.text)
+			res.WriteByte('\n')
+		}
+		oldLine += oldCount
+		newLine += newCount
+		start = end
+	}
+	return res.String()
+}
+
+func splitLines(content string) []string {
+	if len(content) == 0 {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// Start is 1 based, empty ranges point at the line before them
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// Longest common subsequence, Dockerfiles are small enough for the quadratic table
+func diffLines(a, b []string) []diffLine {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	res := []diffLine{}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			res = append(res, diffLine{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			res = append(res, diffLine{'-', a[i]})
+			i++
+		default:
+			res = append(res, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		res = append(res, diffLine{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		res = append(res, diffLine{'+', b[j]})
+	}
+	return res
+}
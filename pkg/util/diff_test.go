@@ -0,0 +1,47 @@
+package util_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/util"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	old := strings.Join([]string{"FROM alpine", "RUN apk add curl", "RUN curl example.com", "COPY . .", "USER app", "WORKDIR /app", "ENV A=1", "ENV B=2", "ENV C=3", "ENV D=4", "CMD [\"app\"]"}, "\n") + "\n"
+	new := strings.Join([]string{"FROM alpine", "RUN apk add curl", "RUN curl -f example.com", "COPY . .", "USER app", "WORKDIR /app", "ENV A=1", "ENV B=2", "ENV C=3", "ENV D=4", "CMD [\"app\"]", "HEALTHCHECK NONE"}, "\n") + "\n"
+	expected := strings.Join([]string{
+		"--- a/Dockerfile",
+		"+++ b/Dockerfile",
+		"@@ -1,6 +1,6 @@",
+		" FROM alpine",
+		" RUN apk add curl",
+		"-RUN curl example.com",
+		"+RUN curl -f example.com",
+		" COPY . .",
+		" USER app",
+		" WORKDIR /app",
+		"@@ -9,3 +9,4 @@",
+		" ENV C=3",
+		" ENV D=4",
+		" CMD [\"app\"]",
+		"+HEALTHCHECK NONE",
+	}, "\n") + "\n"
+	actual := util.UnifiedDiff("a/Dockerfile", "b/Dockerfile", old, new)
+	if actual != expected {
+		t.Errorf("Diff mismatch: Expected\n%s\nGot\n%s", expected, actual)
+	}
+}
+
+func TestUnifiedDiffEqual(t *testing.T) {
+	if actual := util.UnifiedDiff("a", "b", "FROM alpine\n", "FROM alpine\n"); actual != "" {
+		t.Errorf("Diff mismatch: Expected empty diff Got %s", actual)
+	}
+}
+
+func TestUnifiedDiffEmpty(t *testing.T) {
+	expected := "--- a\n+++ b\n@@ -0,0 +1 @@\n+FROM alpine\n"
+	if actual := util.UnifiedDiff("a", "b", "", "FROM alpine\n"); actual != expected {
+		t.Errorf("Diff mismatch: Expected %q Got %q", expected, actual)
+	}
+}
@@ -0,0 +1,80 @@
+package util
+
+import (
+	"reflect"
+	"strings"
+)
+
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// Subset of JSON Schema needed to describe the config and ruleset formats
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Id                   string                 `json:"$id,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	AdditionalProperties any                    `json:"additionalProperties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+}
+
+// Generate a schema for the given type based on the field names in the given struct tag (e.g. yaml or mapstructure)
+// Descriptions are taken from the desc tag, fields tagged with "-" and interface fields are skipped
+// Structs do not allow additional properties
+func GenerateJSONSchema(t reflect.Type, tagName string) *JSONSchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &JSONSchema{Type: "array", Items: GenerateJSONSchema(t.Elem(), tagName)}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: GenerateJSONSchema(t.Elem(), tagName)}
+	case reflect.Struct:
+		schema := &JSONSchema{Type: "object", Properties: map[string]*JSONSchema{}, AdditionalProperties: false}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() || field.Type.Kind() == reflect.Interface {
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get(tagName), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			property := GenerateJSONSchema(field.Type, tagName)
+			property.Description = field.Tag.Get("desc")
+			schema.Properties[name] = property
+		}
+		return schema
+	default:
+		// Any value is allowed
+		return &JSONSchema{}
+	}
+}
+
+// Allow the values in lower, title and upper case as the loaders normalize the case
+func CaseInsensitiveEnum(values []string) []string {
+	enum := make([]string, 0, len(values)*3)
+	for _, value := range values {
+		enum = append(enum, strings.ToLower(value))
+		if len(value) > 0 {
+			enum = append(enum, strings.ToUpper(value[:1])+strings.ToLower(value[1:]))
+		}
+		enum = append(enum, strings.ToUpper(value))
+	}
+	return enum
+}
@@ -0,0 +1,59 @@
+package util_test
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/util"
+)
+
+type schemaTestInner struct {
+	Values []int `yaml:"values"`
+}
+
+type schemaTestType struct {
+	Name     string                     `yaml:"name" desc:"The name"`
+	Enabled  bool                       `yaml:"enabled,omitempty"`
+	Inner    *schemaTestInner           `yaml:"inner"`
+	Params   map[string]any             `yaml:"params"`
+	Ignored  string                     `yaml:"-"`
+	Nested   map[string]schemaTestInner `yaml:"nested"`
+	Untagged float64
+	private  string
+}
+
+func TestGenerateJSONSchema(t *testing.T) {
+	schema := util.GenerateJSONSchema(reflect.TypeOf(schemaTestType{}), "yaml")
+
+	if schema.Type != "object" || schema.AdditionalProperties != false {
+		t.Errorf("Root mismatch: Expected closed object Got %s %v", schema.Type, schema.AdditionalProperties)
+	}
+	keys := []string{}
+	for key := range schema.Properties {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	if expected := []string{"enabled", "inner", "name", "nested", "params", "untagged"}; !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Properties mismatch: Expected %v Got %v", expected, keys)
+	}
+	if schema.Properties["name"].Description != "The name" {
+		t.Errorf("Description mismatch: Expected 'The name' Got '%s'", schema.Properties["name"].Description)
+	}
+	if items := schema.Properties["inner"].Properties["values"].Items; items == nil || items.Type != "integer" {
+		t.Errorf("Items mismatch: Expected integer Got %v", items)
+	}
+	if nested, ok := schema.Properties["nested"].AdditionalProperties.(*util.JSONSchema); !ok || nested.Type != "object" {
+		t.Errorf("Map value mismatch: Expected object Got %v", schema.Properties["nested"].AdditionalProperties)
+	}
+	if schema.Properties["untagged"].Type != "number" {
+		t.Errorf("Type mismatch: Expected number Got %s", schema.Properties["untagged"].Type)
+	}
+}
+
+func TestCaseInsensitiveEnum(t *testing.T) {
+	expected := []string{"fs", "Fs", "FS", "command", "Command", "COMMAND"}
+	if actual := util.CaseInsensitiveEnum([]string{"fs", "Command"}); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Enum mismatch: Expected %v Got %v", expected, actual)
+	}
+}
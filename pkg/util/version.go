@@ -0,0 +1,17 @@
+package util
+
+import "runtime/debug"
+
+// Set at build time using -ldflags "-X github.com/coffeemakingtoaster/whale-watcher/pkg/util.Version=<version>"
+var Version = ""
+
+// Version of the executable, falls back to the module version for go install builds
+func GetVersion() string {
+	if len(Version) > 0 {
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && len(info.Main.Version) > 0 && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
@@ -0,0 +1,123 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	violationTypes "github.com/coffeemakingtoaster/whale-watcher/pkg/validator/violations"
+	"github.com/spf13/viper"
+)
+
+const baselineVersion = 1
+
+// Known violations, only violations that are not part of the baseline fail the run
+type Baseline struct {
+	Version    int             `json:"version"`
+	Violations []BaselineEntry `json:"violations"`
+}
+
+type BaselineEntry struct {
+	RuleId      string `json:"rule_id"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// Record the violations that currently count including already baselined ones
+// Suppressed and waived violations do not need to be part of the baseline
+func NewBaseline(violations violationTypes.Violations) Baseline {
+	baseline := Baseline{Version: baselineVersion, Violations: []BaselineEntry{}}
+	for _, violation := range violations.Violations {
+		if violation.IsExcepted() && !violation.Baselined {
+			continue
+		}
+		baseline.Violations = append(baseline.Violations, BaselineEntry{RuleId: violation.RuleId, Fingerprint: violation.Fingerprint()})
+	}
+	slices.SortFunc(baseline.Violations, func(a, b BaselineEntry) int {
+		if c := strings.Compare(a.RuleId, b.RuleId); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Fingerprint, b.Fingerprint)
+	})
+	return baseline
+}
+
+func LoadBaseline(path string) (Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Baseline{}, err
+	}
+	baseline := Baseline{}
+	if err = json.Unmarshal(data, &baseline); err != nil {
+		return Baseline{}, fmt.Errorf("Could not parse baseline %s: %s", path, err.Error())
+	}
+	if baseline.Version != baselineVersion {
+		return Baseline{}, fmt.Errorf("Unsupported baseline version %d in %s (supported: %d)", baseline.Version, path, baselineVersion)
+	}
+	return baseline, nil
+}
+
+// Load the baseline passed via --baseline, without one every violation is new
+func loadConfiguredBaseline() (*Baseline, error) {
+	path := viper.GetString("baseline")
+	if len(path) == 0 {
+		return nil, nil
+	}
+	baseline, err := LoadBaseline(path)
+	if err != nil {
+		return nil, err
+	}
+	return &baseline, nil
+}
+
+func (b Baseline) Write(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Tracks which baseline entries were matched by violations of the current run
+// The same fingerprint can be part of the baseline multiple times, each entry matches one violation
+type baselineMatcher struct {
+	remaining map[BaselineEntry]int
+}
+
+func newBaselineMatcher(baseline *Baseline) *baselineMatcher {
+	matcher := &baselineMatcher{remaining: make(map[BaselineEntry]int)}
+	if baseline == nil {
+		return matcher
+	}
+	for _, entry := range baseline.Violations {
+		matcher.remaining[entry]++
+	}
+	return matcher
+}
+
+func (m *baselineMatcher) match(violation violationTypes.Violation) bool {
+	entry := BaselineEntry{RuleId: violation.RuleId, Fingerprint: violation.Fingerprint()}
+	if m.remaining[entry] == 0 {
+		return false
+	}
+	m.remaining[entry]--
+	return true
+}
+
+// Entries of checked rules that were not matched, these violations were fixed since the baseline was recorded
+func (m *baselineMatcher) unmatched(checkedIds map[string]bool) []BaselineEntry {
+	entries := []BaselineEntry{}
+	for entry, count := range m.remaining {
+		if !checkedIds[entry.RuleId] {
+			continue
+		}
+		for range count {
+			entries = append(entries, entry)
+		}
+	}
+	slices.SortFunc(entries, func(a, b BaselineEntry) int {
+		return strings.Compare(a.RuleId, b.RuleId)
+	})
+	return entries
+}
@@ -0,0 +1,97 @@
+package validator_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/rules"
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/validator"
+	violationTypes "github.com/coffeemakingtoaster/whale-watcher/pkg/validator/violations"
+	"github.com/spf13/viper"
+)
+
+func TestBaselineRoundtrip(t *testing.T) {
+	violations := violationTypes.Violations{
+		Violations: []violationTypes.Violation{
+			{RuleId: "b"},
+			{RuleId: "a"},
+			{RuleId: "suppressed", Suppressed: true},
+			{RuleId: "baselined", Baselined: true},
+		},
+	}
+	baseline := validator.NewBaseline(violations)
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if err := baseline.Write(path); err != nil {
+		t.Fatal(err)
+	}
+	actual, err := validator.LoadBaseline(path)
+	if err != nil {
+		t.Fatalf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+	ids := []string{}
+	for _, entry := range actual.Violations {
+		ids = append(ids, entry.RuleId)
+	}
+	if expected := []string{"a", "b", "baselined"}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("Baseline mismatch: Expected %v Got %v", expected, ids)
+	}
+}
+
+func TestLoadBaselineUnsupportedVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if err := os.WriteFile(path, []byte(`{"version": 2, "violations": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := validator.LoadBaseline(path); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+func TestValidateWithBaseline(t *testing.T) {
+	baseline := validator.NewBaseline(violationTypes.Violations{
+		Violations: []violationTypes.Violation{{RuleId: "known"}, {RuleId: "fixed"}, {RuleId: "not_checked"}},
+	})
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if err := baseline.Write(path); err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("baseline", path)
+	viper.Set("target_list", "command")
+	defer viper.Reset()
+
+	failingRunner := MockRunner{func(_ bool) error { return errors.New("No") }}
+	passingRunner := MockRunner{func(_ bool) error { return nil }}
+	input := rules.RuleSet{
+		Rules: []*rules.Rule{
+			{Id: "known", Target: "command", Runner: failingRunner},
+			{Id: "new", Target: "command", Runner: failingRunner},
+			{Id: "fixed", Target: "command", Runner: passingRunner},
+			{Id: "not_checked", Target: "os", Runner: passingRunner},
+		},
+	}
+	actual := validator.ValidateRuleset(input, "", "", "")
+	if actual.BaselinedCount != 1 || actual.ViolationCount != 1 || actual.FailingCount != 1 {
+		t.Errorf("Count mismatch: Expected 1 baselined and 1 failing Got %d baselined %d violations %d failing", actual.BaselinedCount, actual.ViolationCount, actual.FailingCount)
+	}
+	if !actual.Violations[0].Baselined || actual.Violations[1].Baselined {
+		t.Errorf("Baselined mismatch: Expected only known to be baselined Got %v", actual.Violations)
+	}
+	if expected := []string{"fixed"}; !reflect.DeepEqual(actual.FixedSinceBaseline, expected) {
+		t.Errorf("Fixed since baseline mismatch: Expected %v Got %v", expected, actual.FixedSinceBaseline)
+	}
+}
+
+func TestFingerprintIgnoresLineAndWhitespace(t *testing.T) {
+	original := violationTypes.Violation{RuleId: "curl_always_use_f", Location: violationTypes.Location{StartLine: 3, Instruction: "RUN apt-get update && \\\n    curl example.com"}}
+	moved := violationTypes.Violation{RuleId: "curl_always_use_f", Location: violationTypes.Location{StartLine: 10, Instruction: "RUN apt-get update &&   curl example.com"}}
+	changed := violationTypes.Violation{RuleId: "curl_always_use_f", Location: violationTypes.Location{StartLine: 3, Instruction: "RUN curl example.org"}}
+	if original.Fingerprint() != moved.Fingerprint() {
+		t.Errorf("Fingerprint mismatch: Expected moved instruction to keep the fingerprint")
+	}
+	if original.Fingerprint() == changed.Fingerprint() {
+		t.Errorf("Fingerprint mismatch: Expected changed instruction to change the fingerprint")
+	}
+}
@@ -1,17 +1,27 @@
 package validator
 
 import (
-	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/coffeemakingtoaster/whale-watcher/pkg/adapters"
 	"github.com/coffeemakingtoaster/whale-watcher/pkg/config"
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/container"
 	"github.com/coffeemakingtoaster/whale-watcher/pkg/rules"
 	"github.com/coffeemakingtoaster/whale-watcher/pkg/runner"
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/util"
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/validator/reports"
 	violationTypes "github.com/coffeemakingtoaster/whale-watcher/pkg/validator/violations"
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/waivers"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
 )
 
 type ValidateContext struct {
@@ -19,6 +29,9 @@ type ValidateContext struct {
 	DockerTarballPath string
 	DockerFilePath    string
 	RuleSetEntrypoint string
+	StartedAt         time.Time
+	// Time it took to load the ruleset including its includes
+	RuleSetLoadDuration time.Duration
 }
 
 func buildContext(input []string) *ValidateContext {
@@ -31,10 +44,55 @@ func buildContext(input []string) *ValidateContext {
 		DockerFilePath:    input[1],
 		OCITarballPath:    input[2],
 		DockerTarballPath: input[3],
+		StartedAt:         time.Now(),
 	}
 }
 
+// Returned if violations at or above the fail threshold were found
+// The exit code reflects the highest severity among these violations
+type ViolationError struct {
+	Severity string
+}
+
+func (e *ViolationError) Error() string {
+	return fmt.Sprintf("Violation found (highest severity: %s)", e.Severity)
+}
+
+// info -> 2 ... critical -> 6
+func (e *ViolationError) ExitCode() int {
+	return 2 + rules.SeverityRank(e.Severity)
+}
+
+// Exit code if rules could not be evaluated and --fail-on-error is set
+const ruleErrorExitCode = 7
+
+// Returned if rules could not be evaluated and errors fail the run
+type RuleEvaluationError struct {
+	Count int
+}
+
+func (e *RuleEvaluationError) Error() string {
+	return fmt.Sprintf("%d rules could not be evaluated", e.Count)
+}
+
+func (e *RuleEvaluationError) ExitCode() int {
+	return ruleErrorExitCode
+}
+
+// Options of validate that are not part of the config
+type validateOptions struct {
+	writeBaselinePath string
+	format            string
+	outputPath        string
+	// Custom template for the html and markdown formats
+	templatePath string
+	profile      bool
+}
+
 func NewCommand() *cobra.Command {
+	var only []string
+	var skip []string
+	var opts validateOptions
 
 	var cmd = &cobra.Command{
 		Use:   "validate [flags] <policyset> <dockerfilepath> [ocitarpath] [dockertarpath]",
@@ -50,6 +108,33 @@ Expected arguments:  <policy set location> <Dockerfile location> [<oci tar locat
 			if len(args) > 4 {
 				return fmt.Errorf("Validate only accepts a maximum 4 arguments (policy set, Dockerfile, oci tar, docker tar) (Got: '%s')", strings.Join(args, " "))
 			}
+			if failOn := viper.GetString("fail_on"); len(failOn) > 0 {
+				if err := rules.VerifySeverity(failOn); err != nil {
+					return fmt.Errorf("fail-on: %s", err.Error())
+				}
+			}
+			if _, err := waivers.LoadConfigured(); err != nil {
+				return fmt.Errorf("waivers: %s", err.Error())
+			}
+			if _, err := loadConfiguredBaseline(); err != nil {
+				return fmt.Errorf("baseline: %s", err.Error())
+			}
+			if err := reports.VerifyFormat(opts.format); err != nil {
+				return err
+			}
+			if len(opts.outputPath) > 0 && opts.format == reports.FormatText {
+				return fmt.Errorf("--output needs a report format (supported: %+q)", reports.Formats[1:])
+			}
+			if err := reports.VerifyTemplate(opts.format, opts.templatePath); err != nil {
+				return err
+			}
+			// Reports written to stdout have to stay parseable, so the console output moves to stderr
+			if opts.format != reports.FormatText && len(opts.outputPath) == 0 {
+				log.Logger = log.Logger.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+			}
+			if err := violationTypes.VerifyPullRequestTemplates(); err != nil {
+				return err
+			}
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -59,17 +144,57 @@ Expected arguments:  <policy set location> <Dockerfile location> [<oci tar locat
 				return err
 			}
 
+			if err = ruleSet.Filter(only, skip); err != nil {
+				return err
+			}
+			ctx.RuleSetLoadDuration = time.Since(ctx.StartedAt)
+
 			if err = isAllowedContext(ctx, ruleSet); err != nil {
 				return err
 			}
 
 			// Fail code if violations were detected
-			if validate(ctx, ruleSet) {
-				return nil
-			}
-			return errors.New("Violation found")
+			return validate(ctx, ruleSet, opts)
 		},
 	}
+
+	validateFlags := pflag.NewFlagSet("Validate Options", pflag.ExitOnError)
+
+	validateFlags.String("fail-on", "", "Lowest severity (info, low, medium, high, critical) that fails the run (default: every violation fails the run) Env: WHALE_WATCHER_FAIL_ON")
+	validateFlags.SetAnnotation("fail-on", "group", []string{validateFlags.Name()})
+	_ = viper.BindPFlag("fail_on", validateFlags.Lookup("fail-on"))
+	validateFlags.Bool("fail-on-error", false, "Fail with exit code 7 if a rule could not be evaluated, violations take precedence Env: WHALE_WATCHER_FAIL_ON_ERROR")
+	validateFlags.SetAnnotation("fail-on-error", "group", []string{validateFlags.Name()})
+	_ = viper.BindPFlag("fail_on_error", validateFlags.Lookup("fail-on-error"))
+	validateFlags.String("waivers", "", "Path of the waiver file (default: "+waivers.DefaultWaiverPath+") Env: WHALE_WATCHER_WAIVERS")
+	validateFlags.SetAnnotation("waivers", "group", []string{validateFlags.Name()})
+	_ = viper.BindPFlag("waivers", validateFlags.Lookup("waivers"))
+	validateFlags.String("baseline", "", "Only fail for violations that are not part of this baseline file Env: WHALE_WATCHER_BASELINE")
+	validateFlags.SetAnnotation("baseline", "group", []string{validateFlags.Name()})
+	_ = viper.BindPFlag("baseline", validateFlags.Lookup("baseline"))
+	validateFlags.String("lockfile", "", "Load remote rulesets at the versions pinned in this lockfile (default: the lockfile beside a local policy set) Env: WHALE_WATCHER_LOCKFILE")
+	validateFlags.SetAnnotation("lockfile", "group", []string{validateFlags.Name()})
+	_ = viper.BindPFlag("lockfile", validateFlags.Lookup("lockfile"))
+	validateFlags.StringVar(&opts.writeBaselinePath, "write-baseline", "", "Record the current violations to this baseline file, the run does not fail for violations")
+	validateFlags.SetAnnotation("write-baseline", "group", []string{validateFlags.Name()})
+	validateFlags.StringArrayVar(&only, "only", []string{}, "Only run rules matching the selector (rule id, glob or tag expression like 'tag:security && !tag:slow'). Can be repeated")
+	validateFlags.SetAnnotation("only", "group", []string{validateFlags.Name()})
+	validateFlags.StringArrayVar(&skip, "skip", []string{}, "Skip rules matching the selector (rule id, glob or tag expression). Can be repeated")
+	validateFlags.SetAnnotation("skip", "group", []string{validateFlags.Name()})
+	validateFlags.StringVar(&opts.format, "format", reports.FormatText, fmt.Sprintf("Report format (%s), text only logs to the console", strings.Join(reports.Formats, ", ")))
+	validateFlags.SetAnnotation("format", "group", []string{validateFlags.Name()})
+	validateFlags.StringVarP(&opts.outputPath, "output", "o", "", "Write the report to this file instead of stdout")
+	validateFlags.SetAnnotation("output", "group", []string{validateFlags.Name()})
+	validateFlags.StringVar(&opts.templatePath, "template", "", fmt.Sprintf("Render the %s or %s report using this Go template file instead of the built-in layout", reports.FormatHtml, reports.FormatMarkdown))
+	validateFlags.SetAnnotation("template", "group", []string{validateFlags.Name()})
+	validateFlags.Bool("no-history", false, "Do not record the run in the run history Env: WHALE_WATCHER_NO_HISTORY")
+	validateFlags.SetAnnotation("no-history", "group", []string{validateFlags.Name()})
+	_ = viper.BindPFlag("no_history", validateFlags.Lookup("no-history"))
+	validateFlags.BoolVar(&opts.profile, "profile", false, fmt.Sprintf("Print the %d slowest rules and the time spent per target level to stderr", reports.ProfileRuleLimit))
+	validateFlags.SetAnnotation("profile", "group", []string{validateFlags.Name()})
+
+	cmd.Flags().AddFlagSet(validateFlags)
+
 	return cmd
 }
 
@@ -98,7 +223,7 @@ func isAllowedContext(ctx *ValidateContext, ruleSet rules.RuleSet) error {
 	return nil
 }
 
-func validate(ctx *ValidateContext, ruleSet rules.RuleSet) bool {
+func validate(ctx *ValidateContext, ruleSet rules.RuleSet, opts validateOptions) error {
 	var err error
 	// Get ref to prevent directory cleanup
 	ref := runner.GetReferencingWorkingDirectoryInstance()
@@ -111,8 +236,21 @@ func validate(ctx *ValidateContext, ruleSet rules.RuleSet) bool {
 
 	violations := getViolations(ctx, ruleSet)
 
+	info := getRunInfo(ctx)
+	recordRun(ctx, violations, ruleSet, info)
+	if err = writeReport(violations, ruleSet, opts, info); err != nil {
+		return err
+	}
+	if opts.profile {
+		// stdout may carry the report
+		if err = reports.WriteProfile(os.Stderr, reports.NewProfile(violations, info), reports.ProfileRuleLimit); err != nil {
+			return err
+		}
+	}
+
 	if config.ShouldInteractWithVSC() {
-		err = adapters.CreatePRForFixes(violations, ref.GetAbsolutePath("./Dockerfile"))
+		fixedDockerfilePath := ref.GetAbsolutePath("./Dockerfile")
+		err = adapters.CreatePRForFixes(violations, fixedDockerfilePath, getPullRequestInfo(ctx, ruleSet, info, fixedDockerfilePath))
 		if err != nil {
 			log.Error().Err(err).Msg("Failed to create PR for changes/fixes")
 		}
@@ -121,21 +259,147 @@ func validate(ctx *ValidateContext, ruleSet rules.RuleSet) bool {
 	}
 
 	if err != nil {
-		return false
+		return err
+	}
+
+	if len(opts.writeBaselinePath) > 0 {
+		baseline := NewBaseline(violations)
+		if err = baseline.Write(opts.writeBaselinePath); err != nil {
+			return err
+		}
+		log.Info().Str("baseline", opts.writeBaselinePath).Int("violations", len(baseline.Violations)).Msg("Baseline written")
+		if violations.ErrorCount > 0 {
+			log.Warn().Int("errors", violations.ErrorCount).Msg("Violations of rules that could not be evaluated are missing from the baseline")
+		}
+		return nil
 	}
 
+	// Broken rules must not hide violations, so violations determine the exit code first
+	if violations.FailingCount > 0 {
+		return &ViolationError{Severity: highestSeverity(violations)}
+	}
+	if violations.ErrorCount > 0 && viper.GetBool("fail_on_error") {
+		return &RuleEvaluationError{Count: violations.ErrorCount}
+	}
 	if violations.ViolationCount > 0 {
-		return false
+		log.Info().Int("violations", violations.ViolationCount).Msg("All violations are below the fail threshold")
+	}
+	return nil
+}
+
+func getRunInfo(ctx *ValidateContext) reports.RunInfo {
+	info := reports.RunInfo{
+		RuleSet:             ctx.RuleSetEntrypoint,
+		Dockerfile:          getDisplayDockerfilePath(ctx.DockerFilePath),
+		Image:               viper.GetString("target.image"),
+		StartedAt:           ctx.StartedAt,
+		Duration:            time.Since(ctx.StartedAt),
+		RuleSetLoadDuration: ctx.RuleSetLoadDuration,
+	}
+	if len(ctx.OCITarballPath) > 0 {
+		digest, err := container.GetImageDigest(ctx.OCITarballPath)
+		if err != nil {
+			log.Warn().Err(err).Msg("Could not determine the image digest")
+		}
+		info.ImageDigest = digest
+	}
+	return info
+}
+
+func getPullRequestInfo(ctx *ValidateContext, ruleSet rules.RuleSet, info reports.RunInfo, fixedDockerfilePath string) violationTypes.PullRequestInfo {
+	res := violationTypes.PullRequestInfo{
+		Dockerfile:     info.Dockerfile,
+		Image:          info.Image,
+		ImageDigest:    info.ImageDigest,
+		RuleSet:        info.RuleSet,
+		RuleSetName:    ruleSet.Name,
+		RuleSetVersion: ruleSet.Version,
+		Version:        util.GetVersion(),
+	}
+	original, err := os.ReadFile(ctx.DockerFilePath)
+	if err != nil {
+		log.Warn().Err(err).Msg("Could not read the Dockerfile, the pull request does not contain the diff")
+		return res
+	}
+	fixed, err := os.ReadFile(fixedDockerfilePath)
+	if err != nil {
+		log.Warn().Err(err).Msg("Could not read the fixed Dockerfile, the pull request does not contain the diff")
+		return res
+	}
+	path := strings.TrimPrefix(filepath.ToSlash(filepath.Clean(info.Dockerfile)), "/")
+	res.Diff = util.UnifiedDiff("a/"+path, "b/"+path, string(original), string(fixed))
+	return res
+}
+
+func writeReport(violations violationTypes.Violations, ruleSet rules.RuleSet, opts validateOptions, info reports.RunInfo) error {
+	if opts.format == reports.FormatText {
+		return nil
+	}
+	var output io.Writer = os.Stdout
+	if opts.outputPath != "" {
+		f, err := os.Create(opts.outputPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		output = f
+	}
+	if len(opts.templatePath) > 0 {
+		return reports.WriteTemplate(output, opts.format, opts.templatePath, violations, ruleSet, info)
+	}
+	return reports.Write(output, opts.format, violations, ruleSet, info)
+}
+
+func highestSeverity(violations violationTypes.Violations) string {
+	highest := ""
+	for _, violation := range violations.Violations {
+		if violation.IsExcepted() {
+			continue
+		}
+		if len(highest) == 0 || rules.SeverityRank(violation.Severity) > rules.SeverityRank(highest) {
+			highest = violation.Severity
+		}
 	}
-	return true
+	return highest
 }
 
 func getViolations(runContext *ValidateContext, ruleSet rules.RuleSet) violationTypes.Violations {
 	// TODO: These paths are passed down way to far without any validation
 	violations := ValidateRuleset(ruleSet, runContext.OCITarballPath, runContext.DockerFilePath, runContext.DockerTarballPath)
-	log.Info().Msgf("Total: %d Violations: %d Fixable: %d", violations.CheckedCount, violations.ViolationCount, violations.FixableCount)
+	log.Info().Msgf("Total: %d Violations: %d Failing: %d Fixable: %d Suppressed: %d Waived: %d Baselined: %d Errors: %d", violations.CheckedCount, violations.ViolationCount, violations.FailingCount, violations.FixableCount, violations.SuppressedCount, violations.WaivedCount, violations.BaselinedCount, violations.ErrorCount)
 	for _, violation := range violations.Violations {
-		log.Warn().Str("ruleId", violation.RuleId).Str("problem", violation.Description).Send()
+		if violation.Suppressed {
+			log.Info().Str("ruleId", violation.RuleId).Str("severity", violation.Severity).Str("source", violation.Source).Str("location", violation.Location.String()).Str("reason", violation.SuppressionReason).Msg("suppressed")
+			continue
+		}
+		if violation.Baselined {
+			log.Info().Str("ruleId", violation.RuleId).Str("severity", violation.Severity).Str("source", violation.Source).Str("location", violation.Location.String()).Msg("baselined")
+			continue
+		}
+		if violation.IsExcepted() {
+			log.Info().Str("ruleId", violation.RuleId).Str("severity", violation.Severity).Str("source", violation.Source).Str("location", violation.Location.String()).Str("owner", violation.Waiver.Owner).Str("expires", violation.Waiver.Expires).Str("justification", violation.Waiver.Justification).Msg("waived")
+			continue
+		}
+		event := log.Warn().Str("ruleId", violation.RuleId).Str("severity", violation.Severity).Str("source", violation.Source).Str("location", violation.Location.String()).Str("problem", violation.Description)
+		if len(violation.Detail()) > 0 {
+			event = event.Str("message", violation.Detail())
+		}
+		if violation.Waiver != nil {
+			event = event.Str("waiverExpired", violation.Waiver.Expires).Str("owner", violation.Waiver.Owner)
+		}
+		event.Send()
+		if violation.Exception != nil {
+			log.Debug().Str("ruleId", violation.RuleId).Str("type", violation.Exception.Type).Msg(violation.Exception.Traceback)
+		}
+	}
+	for _, ruleError := range violations.Errors {
+		log.Error().Str("ruleId", ruleError.RuleId).Str("source", ruleError.Source).Str("error", ruleError.Detail()).Msg("could not be evaluated")
+		if ruleError.Exception != nil {
+			log.Debug().Str("ruleId", ruleError.RuleId).Str("type", ruleError.Exception.Type).Msg(ruleError.Exception.Traceback)
+		}
+	}
+	for _, ruleId := range violations.FixedSinceBaseline {
+		log.Info().Str("ruleId", ruleId).Msg("Fixed since the baseline was recorded, the baseline can be tightened")
 	}
 	return violations
 }
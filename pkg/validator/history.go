@@ -0,0 +1,75 @@
+package validator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/history"
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/rules"
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/util"
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/validator/reports"
+	violationTypes "github.com/coffeemakingtoaster/whale-watcher/pkg/validator/violations"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+func newHistoryRun(ctx *ValidateContext, violations violationTypes.Violations, ruleSet rules.RuleSet, info reports.RunInfo) history.Run {
+	run := history.Run{
+		Dockerfile:     info.Dockerfile,
+		Repository:     viper.GetString("target.repository"),
+		Image:          info.Image,
+		ImageDigest:    info.ImageDigest,
+		RuleSet:        info.RuleSet,
+		RuleSetName:    ruleSet.Name,
+		RuleSetVersion: ruleSet.Version,
+		ToolVersion:    util.GetVersion(),
+		StartedAt:      info.StartedAt,
+		Duration:       info.Duration,
+		Checked:        violations.CheckedCount,
+		Violations:     violations.ViolationCount,
+		Failing:        violations.FailingCount,
+		Errors:         violations.ErrorCount,
+		Rules:          []history.RuleOutcome{},
+	}
+	if len(run.Repository) == 0 {
+		repository, dockerfile, err := history.GetLocalTarget(ctx.DockerFilePath)
+		if err != nil {
+			log.Warn().Err(err).Msg("Could not resolve the Dockerfile for the run history")
+			dockerfile = filepath.Clean(ctx.DockerFilePath)
+		}
+		run.Repository = repository
+		run.Dockerfile = dockerfile
+	}
+	if content, err := os.ReadFile(ctx.DockerFilePath); err == nil {
+		hash := sha256.Sum256(content)
+		run.DockerfileHash = hex.EncodeToString(hash[:])
+	} else {
+		log.Warn().Err(err).Msg("Could not hash the Dockerfile for the run history")
+	}
+
+	counting := make(map[string]int)
+	for _, violation := range violations.Violations {
+		if !violation.IsExcepted() {
+			counting[violation.RuleId]++
+		}
+	}
+	for _, result := range violations.Rules {
+		run.Rules = append(run.Rules, history.RuleOutcome{Id: result.RuleId, Status: result.Status, Violations: counting[result.RuleId]})
+	}
+	return run
+}
+
+// A failing history must not fail the validation
+func recordRun(ctx *ValidateContext, violations violationTypes.Violations, ruleSet rules.RuleSet, info reports.RunInfo) {
+	if viper.GetBool("no_history") {
+		return
+	}
+	id, err := history.Record(newHistoryRun(ctx, violations, ruleSet, info))
+	if err != nil {
+		log.Warn().Err(err).Msg("Could not record the run in the run history")
+		return
+	}
+	log.Debug().Uint64("run", id).Msg("Run recorded in the run history")
+}
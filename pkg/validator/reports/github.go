@@ -0,0 +1,53 @@
+package reports
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/rules"
+	violationTypes "github.com/coffeemakingtoaster/whale-watcher/pkg/validator/violations"
+)
+
+// GitHub Actions workflow commands, one annotation per violation that counts and per rule that could not be evaluated
+// See https://docs.github.com/en/actions/reference/workflow-commands-for-github-actions
+func writeGithub(w io.Writer, violations violationTypes.Violations, _ rules.RuleSet, _ RunInfo) error {
+	for _, violation := range violations.Violations {
+		if violation.IsExcepted() {
+			continue
+		}
+		properties := []string{"file=" + escapeGithubProperty(toRepositoryPath(violation.Location.File))}
+		if violation.Location.StartLine > 0 {
+			properties = append(properties, fmt.Sprintf("line=%d", violation.Location.StartLine), fmt.Sprintf("endLine=%d", max(violation.Location.EndLine, violation.Location.StartLine)))
+		}
+		properties = append(properties, "title="+escapeGithubProperty(fmt.Sprintf("%s (%s)", violation.RuleId, violation.Severity)))
+		if _, err := fmt.Fprintf(w, "::%s %s::%s\n", githubCommand(violation.Severity), strings.Join(properties, ","), escapeGithubData(getMessage(violation))); err != nil {
+			return err
+		}
+	}
+	for _, ruleError := range violations.Errors {
+		if _, err := fmt.Fprintf(w, "::error title=%s::%s\n", escapeGithubProperty(ruleError.RuleId+" could not be evaluated"), escapeGithubData(ruleError.Detail())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func githubCommand(severity string) string {
+	switch getLevel(severity) {
+	case levelError:
+		return "error"
+	case levelWarning:
+		return "warning"
+	default:
+		return "notice"
+	}
+}
+
+func escapeGithubData(value string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(value)
+}
+
+func escapeGithubProperty(value string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(value)
+}
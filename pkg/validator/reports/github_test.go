@@ -0,0 +1,39 @@
+package reports_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/validator/reports"
+	violationTypes "github.com/coffeemakingtoaster/whale-watcher/pkg/validator/violations"
+)
+
+func TestWriteGithub(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := reports.Write(&buffer, reports.FormatGithub, reportViolations, reportRuleSet, reports.RunInfo{}); err != nil {
+		t.Fatalf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+	expected := []string{
+		"::error file=Dockerfile,line=3,endLine=4,title=curl_fail (high)::curl is called without -f",
+		"::error title=broken could not be evaluated::FileNotFoundError: file not found",
+	}
+	actual := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Output mismatch: Expected %q Got %q", expected, actual)
+	}
+}
+
+func TestWriteGithubEscaping(t *testing.T) {
+	violations := violationTypes.Violations{Violations: []violationTypes.Violation{
+		{RuleId: "odd:id,1", Severity: "info", Message: "100% wrong\nsecond line", Location: violationTypes.Location{File: "dir/Dockerfile", StageIndex: -1}},
+	}}
+	var buffer bytes.Buffer
+	if err := reports.Write(&buffer, reports.FormatGithub, violations, reportRuleSet, reports.RunInfo{}); err != nil {
+		t.Fatalf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+	expected := "::notice file=dir/Dockerfile,title=odd%3Aid%2C1 (info)::100%25 wrong%0Asecond line\n"
+	if buffer.String() != expected {
+		t.Errorf("Output mismatch: Expected %q Got %q", expected, buffer.String())
+	}
+}
@@ -0,0 +1,74 @@
+package reports
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/rules"
+	violationTypes "github.com/coffeemakingtoaster/whale-watcher/pkg/validator/violations"
+)
+
+// Issue of the GitLab Code Quality report
+type gitlabIssue struct {
+	Description string         `json:"description"`
+	CheckName   string         `json:"check_name"`
+	Fingerprint string         `json:"fingerprint"`
+	Severity    string         `json:"severity"`
+	Location    gitlabLocation `json:"location"`
+}
+
+type gitlabLocation struct {
+	Path  string      `json:"path"`
+	Lines gitlabLines `json:"lines"`
+}
+
+type gitlabLines struct {
+	Begin int `json:"begin"`
+	End   int `json:"end"`
+}
+
+// Only violations that count are reported, GitLab has no notion of suppressed issues
+func writeGitlab(w io.Writer, violations violationTypes.Violations, _ rules.RuleSet, _ RunInfo) error {
+	issues := []gitlabIssue{}
+	// GitLab requires unique fingerprints, findings without a location share one
+	occurrences := make(map[string]int)
+	for _, violation := range violations.Violations {
+		if violation.IsExcepted() {
+			continue
+		}
+		fingerprint := violation.Fingerprint()
+		occurrences[fingerprint]++
+		issues = append(issues, gitlabIssue{
+			Description: fmt.Sprintf("%s: %s", violation.RuleId, getMessage(violation)),
+			CheckName:   violation.RuleId,
+			Fingerprint: fmt.Sprintf("%x", sha256.Sum256(fmt.Appendf(nil, "%s\n%d", fingerprint, occurrences[fingerprint]))),
+			Severity:    gitlabSeverity(violation.Severity),
+			Location: gitlabLocation{
+				Path: toRepositoryPath(violation.Location.File),
+				// Violations without a location are attached to the first line
+				Lines: gitlabLines{Begin: max(violation.Location.StartLine, 1), End: max(violation.Location.EndLine, violation.Location.StartLine, 1)},
+			},
+		})
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(issues)
+}
+
+func gitlabSeverity(severity string) string {
+	switch rules.SeverityRank(severity) {
+	case rules.SeverityRank("critical"):
+		return "blocker"
+	case rules.SeverityRank("high"):
+		return "critical"
+	case rules.SeverityRank("medium"):
+		return "major"
+	case rules.SeverityRank("low"):
+		return "minor"
+	default:
+		return "info"
+	}
+}
@@ -0,0 +1,64 @@
+package reports_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/validator/reports"
+	violationTypes "github.com/coffeemakingtoaster/whale-watcher/pkg/validator/violations"
+)
+
+type gitlabIssue struct {
+	Description string
+	CheckName   string `json:"check_name"`
+	Fingerprint string
+	Severity    string
+	Location    struct {
+		Path  string
+		Lines struct{ Begin, End int }
+	}
+}
+
+func TestWriteGitlab(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := reports.Write(&buffer, reports.FormatGitlab, reportViolations, reportRuleSet, reports.RunInfo{}); err != nil {
+		t.Fatalf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+	actual := []gitlabIssue{}
+	if err := json.Unmarshal(buffer.Bytes(), &actual); err != nil {
+		t.Fatalf("Invalid json: %s", err.Error())
+	}
+	// The suppressed violation is not reported
+	if len(actual) != 1 {
+		t.Fatalf("Issue count mismatch: Expected 1 Got %d", len(actual))
+	}
+	issue := actual[0]
+	if issue.CheckName != "curl_fail" || issue.Severity != "critical" || issue.Description != "curl_fail: curl is called without -f" {
+		t.Errorf("Issue mismatch: Got %+v", issue)
+	}
+	if issue.Location.Path != "Dockerfile" || issue.Location.Lines.Begin != 3 || issue.Location.Lines.End != 4 || len(issue.Fingerprint) == 0 {
+		t.Errorf("Location mismatch: Got %+v", issue.Location)
+	}
+}
+
+func TestWriteGitlabUniqueFingerprints(t *testing.T) {
+	violation := violationTypes.Violation{RuleId: "no_root", Description: "Do not run as root", Severity: "critical", Location: violationTypes.Location{File: "Dockerfile", StageIndex: -1}}
+	var buffer bytes.Buffer
+	if err := reports.Write(&buffer, reports.FormatGitlab, violationTypes.Violations{Violations: []violationTypes.Violation{violation, violation}}, reportRuleSet, reports.RunInfo{}); err != nil {
+		t.Fatalf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+	actual := []gitlabIssue{}
+	if err := json.Unmarshal(buffer.Bytes(), &actual); err != nil {
+		t.Fatalf("Invalid json: %s", err.Error())
+	}
+	if len(actual) != 2 {
+		t.Fatalf("Issue count mismatch: Expected 2 Got %d", len(actual))
+	}
+	if actual[0].Fingerprint == actual[1].Fingerprint {
+		t.Errorf("Fingerprint mismatch: Expected unique fingerprints Got %s twice", actual[0].Fingerprint)
+	}
+	if actual[0].Severity != "blocker" || actual[0].Location.Lines.Begin != 1 || actual[0].Location.Lines.End != 1 {
+		t.Errorf("Issue mismatch: Got %+v", actual[0])
+	}
+}
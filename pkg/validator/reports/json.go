@@ -0,0 +1,251 @@
+package reports
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/rules"
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/util"
+	violationTypes "github.com/coffeemakingtoaster/whale-watcher/pkg/validator/violations"
+)
+
+// Bump on breaking changes to the report structure, added fields are not breaking
+const JsonReportVersion = 1
+
+type JsonReport struct {
+	Version int               `json:"version"`
+	Tool    JsonTool          `json:"tool"`
+	Run     JsonRun           `json:"run"`
+	Summary JsonSummary       `json:"summary"`
+	Rules   []JsonRuleOutcome `json:"rules"`
+	// Rule ids of baseline entries that no longer occur
+	FixedSinceBaseline []string `json:"fixed_since_baseline"`
+}
+
+type JsonTool struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type JsonRun struct {
+	RuleSet     string    `json:"ruleset"`
+	Dockerfile  string    `json:"dockerfile"`
+	Image       string    `json:"image"`
+	ImageDigest string    `json:"image_digest"`
+	StartedAt   time.Time `json:"started_at"`
+	DurationMs  float64   `json:"duration_ms"`
+	// Time spent loading the ruleset including its includes
+	RuleSetLoadMs float64 `json:"ruleset_load_ms"`
+	// Time spent on the rules of each target level
+	Targets []JsonTargetTiming `json:"targets"`
+}
+
+type JsonTargetTiming struct {
+	Target     string  `json:"target"`
+	Rules      int     `json:"rules"`
+	DurationMs float64 `json:"duration_ms"`
+	PopulateMs float64 `json:"populate_ms"`
+	SetupMs    float64 `json:"setup_ms"`
+	FixMs      float64 `json:"fix_ms"`
+}
+
+type JsonSummary struct {
+	Checked    int `json:"checked"`
+	Violations int `json:"violations"`
+	Failing    int `json:"failing"`
+	Fixable    int `json:"fixable"`
+	Suppressed int `json:"suppressed"`
+	Waived     int `json:"waived"`
+	Baselined  int `json:"baselined"`
+	Errors     int `json:"errors"`
+}
+
+type JsonRuleOutcome struct {
+	Id          string   `json:"id"`
+	Description string   `json:"description"`
+	Severity    string   `json:"severity"`
+	Source      string   `json:"source"`
+	Tags        []string `json:"tags"`
+	// One of passed, violated, excepted, errored and skipped
+	Status     string  `json:"status"`
+	SkipReason string  `json:"skip_reason,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+	// Parts of the duration spent populating the working directory and loading the Dockerfile and image
+	PopulateMs float64 `json:"populate_ms"`
+	SetupMs    float64 `json:"setup_ms"`
+	// Time spent running the fix instruction, not part of the duration
+	FixMs      float64         `json:"fix_ms"`
+	Violations []JsonViolation `json:"violations"`
+	Error      *JsonError      `json:"error,omitempty"`
+}
+
+type JsonViolation struct {
+	Severity    string       `json:"severity"`
+	Message     string       `json:"message"`
+	Location    JsonLocation `json:"location"`
+	Fingerprint string       `json:"fingerprint"`
+	Fixable     bool         `json:"fixable"`
+	AutoFixed   bool         `json:"auto_fixed"`
+	Suppression *JsonReason  `json:"suppression,omitempty"`
+	Waiver      *JsonWaiver  `json:"waiver,omitempty"`
+	Baselined   bool         `json:"baselined"`
+	Exception   *JsonError   `json:"exception,omitempty"`
+}
+
+type JsonLocation struct {
+	File string `json:"file"`
+	// 0 if the rule did not attach a location
+	StartLine   int    `json:"start_line"`
+	EndLine     int    `json:"end_line"`
+	StageIndex  int    `json:"stage_index"`
+	StageName   string `json:"stage_name"`
+	Instruction string `json:"instruction"`
+}
+
+type JsonReason struct {
+	Reason string `json:"reason"`
+}
+
+type JsonWaiver struct {
+	Owner         string `json:"owner"`
+	Justification string `json:"justification"`
+	Expires       string `json:"expires"`
+	Expired       bool   `json:"expired"`
+}
+
+type JsonError struct {
+	Type      string `json:"type"`
+	Message   string `json:"message"`
+	Traceback string `json:"traceback"`
+}
+
+func writeJson(w io.Writer, violations violationTypes.Violations, ruleSet rules.RuleSet, info RunInfo) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(NewJsonReport(violations, ruleSet, info))
+}
+
+func NewJsonReport(violations violationTypes.Violations, ruleSet rules.RuleSet, info RunInfo) JsonReport {
+	report := JsonReport{
+		Version: JsonReportVersion,
+		Tool:    JsonTool{Name: toolName, Version: util.GetVersion()},
+		Run: JsonRun{
+			RuleSet:       info.RuleSet,
+			Dockerfile:    info.Dockerfile,
+			Image:         info.Image,
+			ImageDigest:   info.ImageDigest,
+			StartedAt:     info.StartedAt,
+			DurationMs:    toMilliseconds(info.Duration),
+			RuleSetLoadMs: toMilliseconds(info.RuleSetLoadDuration),
+			Targets:       []JsonTargetTiming{},
+		},
+		Summary: JsonSummary{
+			Checked:    violations.CheckedCount,
+			Violations: violations.ViolationCount,
+			Failing:    violations.FailingCount,
+			Fixable:    violations.FixableCount,
+			Suppressed: violations.SuppressedCount,
+			Waived:     violations.WaivedCount,
+			Baselined:  violations.BaselinedCount,
+			Errors:     violations.ErrorCount,
+		},
+		Rules:              []JsonRuleOutcome{},
+		FixedSinceBaseline: []string{},
+	}
+	report.FixedSinceBaseline = append(report.FixedSinceBaseline, violations.FixedSinceBaseline...)
+	for _, target := range NewProfile(violations, info).Targets {
+		report.Run.Targets = append(report.Run.Targets, JsonTargetTiming{
+			Target:     target.Target,
+			Rules:      target.Rules,
+			DurationMs: toMilliseconds(target.Duration),
+			PopulateMs: toMilliseconds(target.Populate),
+			SetupMs:    toMilliseconds(target.Setup),
+			FixMs:      toMilliseconds(target.Fix),
+		})
+	}
+
+	rulesById := make(map[string]*rules.Rule)
+	for _, rule := range ruleSet.Rules {
+		rulesById[rule.Id] = rule
+	}
+	violationsByRule := make(map[string][]violationTypes.Violation)
+	for _, violation := range violations.Violations {
+		violationsByRule[violation.RuleId] = append(violationsByRule[violation.RuleId], violation)
+	}
+	errorsByRule := make(map[string]violationTypes.RuleError)
+	for _, ruleError := range violations.Errors {
+		errorsByRule[ruleError.RuleId] = ruleError
+	}
+
+	for _, result := range violations.Rules {
+		outcome := JsonRuleOutcome{
+			Id:         result.RuleId,
+			Tags:       []string{},
+			Status:     result.Status,
+			SkipReason: result.SkipReason,
+			DurationMs: toMilliseconds(result.Duration),
+			PopulateMs: toMilliseconds(result.PopulateDuration),
+			SetupMs:    toMilliseconds(result.SetupDuration),
+			FixMs:      toMilliseconds(result.FixDuration),
+			Violations: []JsonViolation{},
+		}
+		if rule, ok := rulesById[result.RuleId]; ok {
+			outcome.Description = rule.Description
+			outcome.Severity = rule.Severity
+			outcome.Source = rule.Source.String()
+			outcome.Tags = append(outcome.Tags, rule.Tags...)
+		}
+		for _, violation := range violationsByRule[result.RuleId] {
+			outcome.Violations = append(outcome.Violations, toJsonViolation(violation))
+		}
+		if ruleError, ok := errorsByRule[result.RuleId]; ok {
+			outcome.Error = &JsonError{Message: ruleError.Message}
+			if ruleError.Exception != nil {
+				outcome.Error.Type = ruleError.Exception.Type
+				outcome.Error.Traceback = ruleError.Exception.Traceback
+			}
+		}
+		report.Rules = append(report.Rules, outcome)
+	}
+	return report
+}
+
+func toJsonViolation(violation violationTypes.Violation) JsonViolation {
+	res := JsonViolation{
+		Severity: violation.Severity,
+		Message:  violation.Message,
+		Location: JsonLocation{
+			File:        violation.Location.File,
+			StartLine:   violation.Location.StartLine,
+			EndLine:     violation.Location.EndLine,
+			StageIndex:  violation.Location.StageIndex,
+			StageName:   violation.Location.StageName,
+			Instruction: violation.Location.Instruction,
+		},
+		Fingerprint: violation.Fingerprint(),
+		Fixable:     violation.Fixable,
+		AutoFixed:   violation.AutoFixed,
+		Baselined:   violation.Baselined,
+	}
+	if violation.Suppressed {
+		res.Suppression = &JsonReason{Reason: violation.SuppressionReason}
+	}
+	if violation.Waiver != nil {
+		res.Waiver = &JsonWaiver{
+			Owner:         violation.Waiver.Owner,
+			Justification: violation.Waiver.Justification,
+			Expires:       violation.Waiver.Expires,
+			Expired:       violation.Waiver.Expired,
+		}
+	}
+	if violation.Exception != nil {
+		res.Exception = &JsonError{Type: violation.Exception.Type, Message: violation.Message, Traceback: violation.Exception.Traceback}
+	}
+	return res
+}
+
+func toMilliseconds(duration time.Duration) float64 {
+	return float64(duration.Microseconds()) / 1000
+}
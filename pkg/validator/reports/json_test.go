@@ -0,0 +1,63 @@
+package reports_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/validator/reports"
+)
+
+func TestWriteJson(t *testing.T) {
+	info := reports.RunInfo{
+		RuleSet:     "ruleset.yaml",
+		Dockerfile:  "./Dockerfile",
+		Image:       "alpine:3",
+		ImageDigest: "sha256:abc",
+		StartedAt:   time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Duration:    1500 * time.Millisecond,
+	}
+	var buffer bytes.Buffer
+	if err := reports.Write(&buffer, reports.FormatJson, reportViolations, reportRuleSet, info); err != nil {
+		t.Fatalf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+	actual := reports.JsonReport{}
+	if err := json.Unmarshal(buffer.Bytes(), &actual); err != nil {
+		t.Fatalf("Invalid json: %s", err.Error())
+	}
+	if actual.Version != reports.JsonReportVersion || actual.Tool.Name != "whale-watcher" || len(actual.Tool.Version) == 0 {
+		t.Errorf("Header mismatch: Got version %d tool %+v", actual.Version, actual.Tool)
+	}
+	if actual.Run.ImageDigest != "sha256:abc" || actual.Run.DurationMs != 1500 || !actual.Run.StartedAt.Equal(info.StartedAt) {
+		t.Errorf("Run mismatch: Got %+v", actual.Run)
+	}
+	if len(actual.Run.Targets) != 2 || actual.Run.Targets[0].Target != "command" || actual.Run.Targets[1].Rules != 1 || actual.Run.Targets[1].SetupMs != 200 {
+		t.Errorf("Target mismatch: Expected command and fs without the skipped os rule Got %+v", actual.Run.Targets)
+	}
+	if actual.Summary.Checked != 3 || actual.Summary.Errors != 1 || actual.Summary.Failing != 1 {
+		t.Errorf("Summary mismatch: Got %+v", actual.Summary)
+	}
+	if len(actual.Rules) != 4 {
+		t.Fatalf("Rule count mismatch: Expected 4 Got %d", len(actual.Rules))
+	}
+	violated := actual.Rules[0]
+	if violated.Status != "violated" || violated.Severity != "high" || len(violated.Tags) != 1 || len(violated.Violations) != 1 {
+		t.Errorf("Rule mismatch: Got %+v", violated)
+	}
+	if violated.Violations[0].Location.StartLine != 3 || violated.Violations[0].Message != "curl is called without -f" || len(violated.Violations[0].Fingerprint) == 0 {
+		t.Errorf("Violation mismatch: Got %+v", violated.Violations[0])
+	}
+	if suppression := actual.Rules[1].Violations[0].Suppression; suppression == nil || suppression.Reason != "interactive on purpose" {
+		t.Errorf("Suppression mismatch: Got %+v", suppression)
+	}
+	if actual.Rules[2].DurationMs != 250 || actual.Rules[2].SetupMs != 200 {
+		t.Errorf("Timing mismatch: Got %+v", actual.Rules[2])
+	}
+	if actual.Rules[2].Error == nil || actual.Rules[2].Error.Type != "FileNotFoundError" || len(actual.Rules[2].Violations) != 0 {
+		t.Errorf("Error mismatch: Got %+v", actual.Rules[2])
+	}
+	if actual.Rules[3].Status != "skipped" || actual.Rules[3].SkipReason != "Target os is not allowed" {
+		t.Errorf("Skip mismatch: Got %+v", actual.Rules[3])
+	}
+}
@@ -0,0 +1,160 @@
+package reports
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/rules"
+	violationTypes "github.com/coffeemakingtoaster/whale-watcher/pkg/validator/violations"
+)
+
+type junitTestSuites struct {
+	XMLName xml.Name `xml:"testsuites"`
+	junitCounts
+	Suites []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	junitCounts
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitCounts struct {
+	Name     string `xml:"name,attr"`
+	Tests    int    `xml:"tests,attr"`
+	Failures int    `xml:"failures,attr"`
+	Errors   int    `xml:"errors,attr"`
+	Skipped  int    `xml:"skipped,attr"`
+	// Seconds
+	Time string `xml:"time,attr"`
+}
+
+type junitTestCase struct {
+	Name      string       `xml:"name,attr"`
+	Classname string       `xml:"classname,attr"`
+	Time      string       `xml:"time,attr"`
+	Failure   *junitResult `xml:"failure,omitempty"`
+	Error     *junitResult `xml:"error,omitempty"`
+	Skipped   *junitResult `xml:"skipped,omitempty"`
+}
+
+type junitResult struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// Every rule is a test case, violated rules fail, errored rules error and skipped or fully excepted rules are skipped
+func writeJunit(w io.Writer, violations violationTypes.Violations, ruleSet rules.RuleSet, info RunInfo) error {
+	suiteName := ruleSet.Name
+	if len(suiteName) == 0 {
+		suiteName = toolName
+	}
+	suite := junitTestSuite{junitCounts: junitCounts{Name: suiteName, Time: toJunitTime(info.Duration)}, TestCases: []junitTestCase{}}
+	rulesById := make(map[string]*rules.Rule)
+	for _, rule := range ruleSet.Rules {
+		rulesById[rule.Id] = rule
+	}
+	violationsByRule := make(map[string][]violationTypes.Violation)
+	for _, violation := range violations.Violations {
+		violationsByRule[violation.RuleId] = append(violationsByRule[violation.RuleId], violation)
+	}
+	errorsByRule := make(map[string]violationTypes.RuleError)
+	for _, ruleError := range violations.Errors {
+		errorsByRule[ruleError.RuleId] = ruleError
+	}
+
+	for _, result := range violations.Rules {
+		testCase := junitTestCase{Name: result.RuleId, Classname: suiteName, Time: toJunitTime(result.Duration)}
+		rule, ok := rulesById[result.RuleId]
+		if ok && len(rule.Source.RuleSetName) > 0 {
+			testCase.Classname = rule.Source.RuleSetName
+		}
+		switch result.Status {
+		case violationTypes.RuleStatusViolated:
+			testCase.Failure = toJunitFailure(violationsByRule[result.RuleId])
+			suite.Failures++
+		case violationTypes.RuleStatusErrored:
+			testCase.Error = toJunitError(errorsByRule[result.RuleId])
+			suite.Errors++
+		case violationTypes.RuleStatusExcepted:
+			testCase.Skipped = &junitResult{Message: getExceptionReasons(violationsByRule[result.RuleId])}
+			suite.Skipped++
+		case violationTypes.RuleStatusSkipped:
+			testCase.Skipped = &junitResult{Message: result.SkipReason}
+			suite.Skipped++
+		}
+		suite.Tests++
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	suites := junitTestSuites{junitCounts: suite.junitCounts, Suites: []junitTestSuite{suite}}
+	suites.Name = toolName
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func toJunitTime(duration time.Duration) string {
+	return strconv.FormatFloat(duration.Seconds(), 'f', 3, 64)
+}
+
+// Only violations that count fail the test case, the type is the highest severity among them
+func toJunitFailure(violations []violationTypes.Violation) *junitResult {
+	res := &junitResult{}
+	var details []string
+	for _, violation := range violations {
+		if violation.IsExcepted() {
+			continue
+		}
+		if len(res.Type) == 0 || rules.SeverityRank(violation.Severity) > rules.SeverityRank(res.Type) {
+			res.Type = violation.Severity
+		}
+		res.Message = violation.Description
+		detail := fmt.Sprintf("%s (%s)", violation.Location.String(), violation.Severity)
+		if len(violation.Detail()) > 0 {
+			detail = fmt.Sprintf("%s: %s", detail, violation.Detail())
+		}
+		if violation.Waiver != nil {
+			detail = fmt.Sprintf("%s (waiver by %s expired on %s)", detail, violation.Waiver.Owner, violation.Waiver.Expires)
+		}
+		details = append(details, detail)
+	}
+	res.Text = strings.Join(details, "\n")
+	return res
+}
+
+func toJunitError(ruleError violationTypes.RuleError) *junitResult {
+	res := &junitResult{Message: ruleError.Detail()}
+	if ruleError.Exception != nil {
+		res.Type = ruleError.Exception.Type
+		res.Text = ruleError.Exception.Traceback
+	}
+	return res
+}
+
+func getExceptionReasons(violations []violationTypes.Violation) string {
+	var reasons []string
+	for _, violation := range violations {
+		switch {
+		case violation.Suppressed:
+			reasons = append(reasons, fmt.Sprintf("Suppressed: %s", violation.SuppressionReason))
+		case violation.Baselined:
+			reasons = append(reasons, "Part of the baseline")
+		case violation.Waiver != nil:
+			reasons = append(reasons, fmt.Sprintf("Waived by %s until %s: %s", violation.Waiver.Owner, violation.Waiver.Expires, violation.Waiver.Justification))
+		}
+	}
+	return strings.Join(reasons, "; ")
+}
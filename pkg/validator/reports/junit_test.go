@@ -0,0 +1,58 @@
+package reports_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/validator/reports"
+)
+
+func TestWriteJunit(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := reports.Write(&buffer, reports.FormatJunit, reportViolations, reportRuleSet, reports.RunInfo{}); err != nil {
+		t.Fatalf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+	type result struct {
+		Message string `xml:"message,attr"`
+		Type    string `xml:"type,attr"`
+		Text    string `xml:",chardata"`
+	}
+	var actual struct {
+		Tests    int `xml:"tests,attr"`
+		Failures int `xml:"failures,attr"`
+		Errors   int `xml:"errors,attr"`
+		Skipped  int `xml:"skipped,attr"`
+		Suites   []struct {
+			TestCases []struct {
+				Name    string  `xml:"name,attr"`
+				Failure *result `xml:"failure"`
+				Error   *result `xml:"error"`
+				Skipped *result `xml:"skipped"`
+			} `xml:"testcase"`
+		} `xml:"testsuite"`
+	}
+	if err := xml.Unmarshal(buffer.Bytes(), &actual); err != nil {
+		t.Fatalf("Invalid xml: %s", err.Error())
+	}
+	if actual.Tests != 4 || actual.Failures != 1 || actual.Errors != 1 || actual.Skipped != 2 {
+		t.Errorf("Count mismatch: Expected 4 tests 1 failure 1 error 2 skipped Got %d %d %d %d", actual.Tests, actual.Failures, actual.Errors, actual.Skipped)
+	}
+	if len(actual.Suites) != 1 || len(actual.Suites[0].TestCases) != 4 {
+		t.Fatalf("Test case mismatch: Expected 1 suite with 4 test cases Got %+v", actual.Suites)
+	}
+	testCases := actual.Suites[0].TestCases
+	failure := testCases[0].Failure
+	if failure == nil || failure.Type != "high" || failure.Text != "./Dockerfile:3-4 (stage 0) (high): curl is called without -f" {
+		t.Errorf("Failure mismatch: Got %+v", failure)
+	}
+	if testCases[1].Skipped == nil || testCases[1].Skipped.Message != "Suppressed: interactive on purpose" {
+		t.Errorf("Skipped mismatch: Expected suppression reason Got %+v", testCases[1].Skipped)
+	}
+	if testCases[2].Error == nil || testCases[2].Error.Message != "FileNotFoundError: file not found" || testCases[2].Error.Text != "Traceback" {
+		t.Errorf("Error mismatch: Got %+v", testCases[2].Error)
+	}
+	if testCases[3].Skipped == nil || testCases[3].Skipped.Message != "Target os is not allowed" {
+		t.Errorf("Skipped mismatch: Expected disallowed target Got %+v", testCases[3].Skipped)
+	}
+}
@@ -0,0 +1,87 @@
+package reports
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/runner"
+	violationTypes "github.com/coffeemakingtoaster/whale-watcher/pkg/validator/violations"
+)
+
+// Number of rules listed by the profile
+const ProfileRuleLimit = 10
+
+// Where the time of a validate run was spent
+type Profile struct {
+	RuleSetLoad time.Duration
+	Total       time.Duration
+	// Evaluated rules, slowest first
+	Rules []violationTypes.RuleResult
+	// Ordered by target level, lowest first
+	Targets []TargetProfile
+}
+
+// Time spent on the rules of a target level
+type TargetProfile struct {
+	Target   string
+	Rules    int
+	Duration time.Duration
+	Populate time.Duration
+	Setup    time.Duration
+	Fix      time.Duration
+}
+
+func NewProfile(violations violationTypes.Violations, info RunInfo) Profile {
+	profile := Profile{RuleSetLoad: info.RuleSetLoadDuration, Total: info.Duration, Rules: []violationTypes.RuleResult{}, Targets: []TargetProfile{}}
+	targets := make(map[string]*TargetProfile)
+	for _, result := range violations.Rules {
+		if result.Status == violationTypes.RuleStatusSkipped {
+			continue
+		}
+		profile.Rules = append(profile.Rules, result)
+		target, ok := targets[result.Target]
+		if !ok {
+			target = &TargetProfile{Target: result.Target}
+			targets[result.Target] = target
+		}
+		target.Rules++
+		target.Duration += result.Duration
+		target.Populate += result.PopulateDuration
+		target.Setup += result.SetupDuration
+		target.Fix += result.FixDuration
+	}
+	slices.SortStableFunc(profile.Rules, func(a, b violationTypes.RuleResult) int {
+		return cmp.Compare(b.Duration+b.FixDuration, a.Duration+a.FixDuration)
+	})
+	for _, target := range targets {
+		profile.Targets = append(profile.Targets, *target)
+	}
+	slices.SortFunc(profile.Targets, func(a, b TargetProfile) int {
+		return cmp.Compare(runner.GetTargetScore(a.Target), runner.GetTargetScore(b.Target))
+	})
+	return profile
+}
+
+// Human readable summary of the slowest rules and the time per target level
+func WriteProfile(w io.Writer, profile Profile, limit int) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Profile (total %s, ruleset loading %s)\n\n", formatDuration(profile.Total), formatDuration(profile.RuleSetLoad))
+	fmt.Fprintln(tw, "TARGET\tRULES\tTOTAL\tPOPULATE\tSETUP\tFIX")
+	for _, target := range profile.Targets {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\n", target.Target, target.Rules, formatDuration(target.Duration), formatDuration(target.Populate), formatDuration(target.Setup), formatDuration(target.Fix))
+	}
+	fmt.Fprintf(tw, "\nSlowest rules\n\n")
+	fmt.Fprintln(tw, "RULE\tTARGET\tSTATUS\tTOTAL\tPOPULATE\tSETUP\tFIX")
+	for _, result := range profile.Rules[:min(limit, len(profile.Rules))] {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", result.RuleId, result.Target, result.Status, formatDuration(result.Duration), formatDuration(result.PopulateDuration), formatDuration(result.SetupDuration), formatDuration(result.FixDuration))
+	}
+	return tw.Flush()
+}
+
+func formatDuration(duration time.Duration) string {
+	return duration.Round(time.Millisecond).String()
+}
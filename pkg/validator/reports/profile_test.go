@@ -0,0 +1,64 @@
+package reports_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/validator/reports"
+	violationTypes "github.com/coffeemakingtoaster/whale-watcher/pkg/validator/violations"
+)
+
+var profileViolations = violationTypes.Violations{
+	Rules: []violationTypes.RuleResult{
+		{RuleId: "user_set", Target: "command", Status: violationTypes.RuleStatusPassed, Duration: 200 * time.Millisecond, SetupDuration: 50 * time.Millisecond},
+		{RuleId: "no_secrets", Target: "fs", Status: violationTypes.RuleStatusViolated, Duration: 3 * time.Second, PopulateDuration: time.Second, SetupDuration: 1500 * time.Millisecond, FixDuration: 100 * time.Millisecond},
+		{RuleId: "os_packages", Target: "os", Status: violationTypes.RuleStatusSkipped, SkipReason: "Target os is not allowed"},
+		{RuleId: "no_suid", Target: "fs", Status: violationTypes.RuleStatusPassed, Duration: 2 * time.Second, SetupDuration: 1800 * time.Millisecond},
+		{RuleId: "healthcheck", Target: "command", Status: violationTypes.RuleStatusErrored, Duration: 100 * time.Millisecond},
+	},
+}
+
+func TestNewProfile(t *testing.T) {
+	profile := reports.NewProfile(profileViolations, reports.RunInfo{Duration: 6 * time.Second, RuleSetLoadDuration: 300 * time.Millisecond})
+	order := []string{}
+	for _, result := range profile.Rules {
+		order = append(order, result.RuleId)
+	}
+	if strings.Join(order, ",") != "no_secrets,no_suid,user_set,healthcheck" {
+		t.Errorf("Order mismatch: Expected skipped rules to be left out and the slowest first Got %v", order)
+	}
+	if len(profile.Targets) != 2 {
+		t.Fatalf("Target count mismatch: Expected 2 Got %d", len(profile.Targets))
+	}
+	command, fs := profile.Targets[0], profile.Targets[1]
+	if command.Target != "command" || command.Rules != 2 || command.Duration != 300*time.Millisecond || command.Setup != 50*time.Millisecond {
+		t.Errorf("Target mismatch: Got %+v", command)
+	}
+	if fs.Target != "fs" || fs.Rules != 2 || fs.Duration != 5*time.Second || fs.Populate != time.Second || fs.Setup != 3300*time.Millisecond || fs.Fix != 100*time.Millisecond {
+		t.Errorf("Target mismatch: Got %+v", fs)
+	}
+}
+
+func TestWriteProfile(t *testing.T) {
+	var buffer bytes.Buffer
+	profile := reports.NewProfile(profileViolations, reports.RunInfo{Duration: 6 * time.Second, RuleSetLoadDuration: 300 * time.Millisecond})
+	if err := reports.WriteProfile(&buffer, profile, 2); err != nil {
+		t.Fatalf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+	output := buffer.String()
+	if !strings.HasPrefix(output, "Profile (total 6s, ruleset loading 300ms)") {
+		t.Errorf("Header mismatch: Got %s", output)
+	}
+	if !strings.Contains(output, "no_secrets") || !strings.Contains(output, "no_suid") || strings.Contains(output, "user_set") {
+		t.Errorf("Rule mismatch: Expected the 2 slowest rules Got %s", output)
+	}
+	found := false
+	for _, line := range strings.Split(output, "\n") {
+		found = found || strings.Join(strings.Fields(line), " ") == "fs 2 5s 1s 3.3s 100ms"
+	}
+	if !found {
+		t.Errorf("Target mismatch: Expected the fs row Got %s", output)
+	}
+}
@@ -0,0 +1,98 @@
+package reports
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/rules"
+	violationTypes "github.com/coffeemakingtoaster/whale-watcher/pkg/validator/violations"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+// Reviewdog diagnostic format
+// See https://github.com/reviewdog/reviewdog/tree/master/proto/rdf
+type rdjsonResult struct {
+	Source      rdjsonSource       `json:"source"`
+	Diagnostics []rdjsonDiagnostic `json:"diagnostics"`
+}
+
+type rdjsonSource struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type rdjsonDiagnostic struct {
+	Message  string         `json:"message"`
+	Location rdjsonLocation `json:"location"`
+	Severity string         `json:"severity"`
+	Code     rdjsonCode     `json:"code"`
+}
+
+type rdjsonLocation struct {
+	Path  string       `json:"path"`
+	Range *rdjsonRange `json:"range,omitempty"`
+}
+
+type rdjsonRange struct {
+	Start rdjsonPosition `json:"start"`
+	End   rdjsonPosition `json:"end"`
+}
+
+type rdjsonPosition struct {
+	Line int `json:"line"`
+}
+
+type rdjsonCode struct {
+	Value string `json:"value"`
+	URL   string `json:"url,omitempty"`
+}
+
+// Only violations that count are reported
+func writeRdjson(w io.Writer, violations violationTypes.Violations, _ rules.RuleSet, _ RunInfo) error {
+	result := rdjsonResult{
+		Source:      rdjsonSource{Name: toolName, URL: toolURL},
+		Diagnostics: []rdjsonDiagnostic{},
+	}
+	docBaseURL := viper.GetString("docs_url")
+	for _, violation := range violations.Violations {
+		if violation.IsExcepted() {
+			continue
+		}
+		diagnostic := rdjsonDiagnostic{
+			Message:  getMessage(violation),
+			Location: rdjsonLocation{Path: toRepositoryPath(violation.Location.File)},
+			Severity: rdjsonSeverity(violation.Severity),
+			Code:     rdjsonCode{Value: violation.RuleId},
+		}
+		if violation.Location.StartLine > 0 {
+			diagnostic.Location.Range = &rdjsonRange{
+				Start: rdjsonPosition{Line: violation.Location.StartLine},
+				End:   rdjsonPosition{Line: max(violation.Location.EndLine, violation.Location.StartLine)},
+			}
+		}
+		if len(docBaseURL) > 0 {
+			u, err := violationTypes.GetDocURL(docBaseURL, violation.RuleId)
+			if err != nil {
+				log.Warn().Err(err).Msg("Could not parse the docs url")
+			}
+			diagnostic.Code.URL = u
+		}
+		result.Diagnostics = append(result.Diagnostics, diagnostic)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(result)
+}
+
+func rdjsonSeverity(severity string) string {
+	switch getLevel(severity) {
+	case levelError:
+		return "ERROR"
+	case levelWarning:
+		return "WARNING"
+	default:
+		return "INFO"
+	}
+}
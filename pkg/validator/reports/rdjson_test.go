@@ -0,0 +1,51 @@
+package reports_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/validator/reports"
+	"github.com/spf13/viper"
+)
+
+func TestWriteRdjson(t *testing.T) {
+	viper.Set("docs_url", "https://docs.example.com/rules")
+	defer viper.Reset()
+
+	var buffer bytes.Buffer
+	if err := reports.Write(&buffer, reports.FormatRdjson, reportViolations, reportRuleSet, reports.RunInfo{}); err != nil {
+		t.Fatalf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+	var actual struct {
+		Source      struct{ Name string }
+		Diagnostics []struct {
+			Message  string
+			Severity string
+			Location struct {
+				Path  string
+				Range *struct {
+					Start struct{ Line int }
+					End   struct{ Line int }
+				}
+			}
+			Code struct{ Value, Url string }
+		}
+	}
+	if err := json.Unmarshal(buffer.Bytes(), &actual); err != nil {
+		t.Fatalf("Invalid json: %s", err.Error())
+	}
+	if actual.Source.Name != "whale-watcher" || len(actual.Diagnostics) != 1 {
+		t.Fatalf("Result mismatch: Expected 1 diagnostic from whale-watcher Got %+v", actual)
+	}
+	diagnostic := actual.Diagnostics[0]
+	if diagnostic.Message != "curl is called without -f" || diagnostic.Severity != "ERROR" || diagnostic.Location.Path != "Dockerfile" {
+		t.Errorf("Diagnostic mismatch: Got %+v", diagnostic)
+	}
+	if diagnostic.Location.Range == nil || diagnostic.Location.Range.Start.Line != 3 || diagnostic.Location.Range.End.Line != 4 {
+		t.Errorf("Range mismatch: Got %+v", diagnostic.Location.Range)
+	}
+	if diagnostic.Code.Value != "curl_fail" || diagnostic.Code.Url != "https://docs.example.com/rules#curl_fail" {
+		t.Errorf("Code mismatch: Got %+v", diagnostic.Code)
+	}
+}
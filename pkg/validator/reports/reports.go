@@ -0,0 +1,116 @@
+package reports
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/rules"
+	violationTypes "github.com/coffeemakingtoaster/whale-watcher/pkg/validator/violations"
+)
+
+const (
+	// Only the console output, no report is written
+	FormatText  = "text"
+	FormatSarif = "sarif"
+	FormatJunit = "junit"
+	FormatJson  = "json"
+	// GitLab Code Quality report
+	FormatGitlab = "gitlab-codequality"
+	// GitHub Actions workflow commands that annotate the Dockerfile
+	FormatGithub = "github-actions"
+	// Reviewdog diagnostic format
+	FormatRdjson = "rdjson"
+	// Self-contained documents for archiving, rendered from a template
+	FormatHtml     = "html"
+	FormatMarkdown = "markdown"
+)
+
+// Supported values of validate --format
+var Formats = []string{FormatText, FormatSarif, FormatJunit, FormatJson, FormatGitlab, FormatGithub, FormatRdjson, FormatHtml, FormatMarkdown}
+
+// Metadata of a validate run
+type RunInfo struct {
+	// Location of the ruleset as passed to validate
+	RuleSet string
+	// Dockerfile path, the path in the repository for remote Dockerfiles
+	Dockerfile string
+	Image      string
+	// Empty if no image was validated
+	ImageDigest string
+	StartedAt   time.Time
+	Duration    time.Duration
+	// Part of Duration spent loading the ruleset including its includes
+	RuleSetLoadDuration time.Duration
+}
+
+const toolName = "whale-watcher"
+const toolURL = "https://github.com/coffeemakingtoaster/whale-watcher"
+
+// Coarse level for formats that only know three levels
+type level int
+
+const (
+	levelInfo level = iota
+	levelWarning
+	levelError
+)
+
+// critical and high are errors, medium is a warning and everything below is informational
+func getLevel(severity string) level {
+	switch rank := rules.SeverityRank(severity); {
+	case rank >= rules.SeverityRank("high"):
+		return levelError
+	case rank == rules.SeverityRank("medium"):
+		return levelWarning
+	default:
+		return levelInfo
+	}
+}
+
+// Message of the reported finding or failed assertion, the rule description otherwise
+func getMessage(violation violationTypes.Violation) string {
+	if detail := violation.Detail(); len(detail) > 0 {
+		return detail
+	}
+	return violation.Description
+}
+
+// Relative paths are kept relative so they are resolved against the repository root
+func toRepositoryPath(file string) string {
+	return filepath.ToSlash(filepath.Clean(file))
+}
+
+func VerifyFormat(format string) error {
+	if !slices.Contains(Formats, format) {
+		return fmt.Errorf("Unsupported format: %s (supported: %+q)", format, Formats)
+	}
+	return nil
+}
+
+// Write the result of a validate run in the given format
+// The ruleset provides the metadata of the rules that were run
+func Write(w io.Writer, format string, violations violationTypes.Violations, ruleSet rules.RuleSet, info RunInfo) error {
+	switch format {
+	case FormatText:
+		return nil
+	case FormatSarif:
+		return writeSarif(w, violations, ruleSet, info)
+	case FormatJunit:
+		return writeJunit(w, violations, ruleSet, info)
+	case FormatJson:
+		return writeJson(w, violations, ruleSet, info)
+	case FormatGitlab:
+		return writeGitlab(w, violations, ruleSet, info)
+	case FormatGithub:
+		return writeGithub(w, violations, ruleSet, info)
+	case FormatRdjson:
+		return writeRdjson(w, violations, ruleSet, info)
+	case FormatHtml, FormatMarkdown:
+		return WriteTemplate(w, format, "", violations, ruleSet, info)
+	default:
+		return VerifyFormat(format)
+	}
+}
@@ -0,0 +1,50 @@
+package reports_test
+
+import (
+	"time"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/rules"
+	violationTypes "github.com/coffeemakingtoaster/whale-watcher/pkg/validator/violations"
+)
+
+var reportRuleSet = rules.RuleSet{
+	Rules: []*rules.Rule{
+		{Id: "curl_fail", Description: "Use curl -f", LongDescription: "Without -f curl ignores http errors", Severity: "high", Tags: []string{"security"}},
+		{Id: "apt_yes", Description: "Use apt-get -y", Severity: "low"},
+		{Id: "broken", Description: "Broken rule", Severity: "medium"},
+		{Id: "os_only", Description: "Needs the os util", Severity: "medium", Target: "os"},
+	},
+}
+
+var reportViolations = violationTypes.Violations{
+	CheckedCount:   3,
+	ViolationCount: 1,
+	FailingCount:   1,
+	ErrorCount:     1,
+	Violations: []violationTypes.Violation{
+		{
+			RuleId:      "curl_fail",
+			Description: "Use curl -f",
+			Severity:    "high",
+			Message:     "curl is called without -f",
+			Location:    violationTypes.Location{File: "./Dockerfile", StartLine: 3, EndLine: 4, StageIndex: 0, Instruction: "RUN curl example.com"},
+		},
+		{
+			RuleId:            "apt_yes",
+			Description:       "Use apt-get -y",
+			Severity:          "low",
+			Location:          violationTypes.Location{File: "./Dockerfile", StageIndex: -1},
+			Suppressed:        true,
+			SuppressionReason: "interactive on purpose",
+		},
+	},
+	Errors: []violationTypes.RuleError{
+		{RuleId: "broken", Message: "file not found", Exception: &violationTypes.Exception{Type: "FileNotFoundError", Traceback: "Traceback"}},
+	},
+	Rules: []violationTypes.RuleResult{
+		{RuleId: "curl_fail", Target: "command", Status: violationTypes.RuleStatusViolated},
+		{RuleId: "apt_yes", Target: "command", Status: violationTypes.RuleStatusExcepted},
+		{RuleId: "broken", Target: "fs", Status: violationTypes.RuleStatusErrored, Duration: 250 * time.Millisecond, SetupDuration: 200 * time.Millisecond},
+		{RuleId: "os_only", Target: "os", Status: violationTypes.RuleStatusSkipped, SkipReason: "Target os is not allowed"},
+	},
+}
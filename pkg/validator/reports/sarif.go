@@ -0,0 +1,231 @@
+package reports
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/rules"
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/util"
+	violationTypes "github.com/coffeemakingtoaster/whale-watcher/pkg/validator/violations"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+const sarifVersion = "2.1.0"
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// Key of the partial fingerprint, bump the version if the fingerprint changes
+const sarifFingerprintKey = "whaleWatcher/v1"
+
+// Subset of SARIF 2.1.0 used by whale watcher
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool         `json:"tool"`
+	Invocations []sarifInvocation `json:"invocations"`
+	Results     []sarifResult     `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	Id                   string              `json:"id"`
+	ShortDescription     *sarifMessage       `json:"shortDescription,omitempty"`
+	FullDescription      *sarifMessage       `json:"fullDescription,omitempty"`
+	HelpURI              string              `json:"helpUri,omitempty"`
+	DefaultConfiguration sarifConfiguration  `json:"defaultConfiguration"`
+	Properties           sarifRuleProperties `json:"properties"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifRuleProperties struct {
+	Tags     []string `json:"tags,omitempty"`
+	Severity string   `json:"severity"`
+	Source   string   `json:"source,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful        bool                `json:"executionSuccessful"`
+	StartTimeUtc               string              `json:"startTimeUtc,omitempty"`
+	EndTimeUtc                 string              `json:"endTimeUtc,omitempty"`
+	ToolExecutionNotifications []sarifNotification `json:"toolExecutionNotifications,omitempty"`
+}
+
+// Rules that could not be evaluated
+type sarifNotification struct {
+	Level          string                  `json:"level"`
+	Message        sarifMessage            `json:"message"`
+	AssociatedRule sarifReportingReference `json:"associatedRule"`
+}
+
+type sarifReportingReference struct {
+	Id    string `json:"id"`
+	Index int    `json:"index"`
+}
+
+type sarifResult struct {
+	RuleId              string             `json:"ruleId"`
+	RuleIndex           int                `json:"ruleIndex"`
+	Level               string             `json:"level"`
+	Message             sarifMessage       `json:"message"`
+	Locations           []sarifLocation    `json:"locations"`
+	PartialFingerprints map[string]string  `json:"partialFingerprints"`
+	Suppressions        []sarifSuppression `json:"suppressions,omitempty"`
+	BaselineState       string             `json:"baselineState,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int           `json:"startLine"`
+	EndLine   int           `json:"endLine"`
+	Snippet   *sarifMessage `json:"snippet,omitempty"`
+}
+
+type sarifSuppression struct {
+	// inSource for pragmas, external for waivers
+	Kind          string `json:"kind"`
+	Justification string `json:"justification,omitempty"`
+}
+
+func writeSarif(w io.Writer, violations violationTypes.Violations, ruleSet rules.RuleSet, info RunInfo) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           toolName,
+			Version:        util.GetVersion(),
+			InformationURI: toolURL,
+			Rules:          []sarifRule{},
+		}},
+		Invocations: []sarifInvocation{{ExecutionSuccessful: true}},
+		Results:     []sarifResult{},
+	}
+	if !info.StartedAt.IsZero() {
+		run.Invocations[0].StartTimeUtc = info.StartedAt.UTC().Format(time.RFC3339)
+		run.Invocations[0].EndTimeUtc = info.StartedAt.Add(info.Duration).UTC().Format(time.RFC3339)
+	}
+	ruleIndex := make(map[string]int)
+	for _, rule := range ruleSet.Rules {
+		ruleIndex[rule.Id] = len(run.Tool.Driver.Rules)
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, toSarifRule(rule))
+	}
+	for _, violation := range violations.Violations {
+		run.Results = append(run.Results, toSarifResult(violation, ruleIndex[violation.RuleId]))
+	}
+	for _, ruleError := range violations.Errors {
+		run.Invocations[0].ToolExecutionNotifications = append(run.Invocations[0].ToolExecutionNotifications, sarifNotification{
+			Level:          "error",
+			Message:        sarifMessage{Text: "Rule could not be evaluated: " + ruleError.Detail()},
+			AssociatedRule: sarifReportingReference{Id: ruleError.RuleId, Index: ruleIndex[ruleError.RuleId]},
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}})
+}
+
+func toSarifRule(rule *rules.Rule) sarifRule {
+	res := sarifRule{
+		Id:                   rule.Id,
+		DefaultConfiguration: sarifConfiguration{Level: sarifLevel(rule.Severity)},
+		Properties:           sarifRuleProperties{Tags: rule.Tags, Severity: rule.Severity, Source: rule.Source.String()},
+	}
+	if len(rule.Description) > 0 {
+		res.ShortDescription = &sarifMessage{Text: rule.Description}
+	}
+	if len(rule.LongDescription) > 0 {
+		res.FullDescription = &sarifMessage{Text: rule.LongDescription}
+	}
+	if docBaseURL := viper.GetString("docs_url"); len(docBaseURL) > 0 {
+		u, err := violationTypes.GetDocURL(docBaseURL, rule.Id)
+		if err != nil {
+			log.Warn().Err(err).Msg("Could not parse the docs url")
+		}
+		res.HelpURI = u
+	}
+	return res
+}
+
+func toSarifResult(violation violationTypes.Violation, ruleIndex int) sarifResult {
+	res := sarifResult{
+		RuleId:              violation.RuleId,
+		RuleIndex:           ruleIndex,
+		Level:               sarifLevel(violation.Severity),
+		Message:             sarifMessage{Text: getMessage(violation)},
+		Locations:           []sarifLocation{{PhysicalLocation: toSarifPhysicalLocation(violation.Location)}},
+		PartialFingerprints: map[string]string{sarifFingerprintKey: violation.Fingerprint()},
+	}
+	if violation.Suppressed {
+		res.Suppressions = append(res.Suppressions, sarifSuppression{Kind: "inSource", Justification: violation.SuppressionReason})
+	}
+	if violation.Waiver != nil && !violation.Waiver.Expired {
+		res.Suppressions = append(res.Suppressions, sarifSuppression{Kind: "external", Justification: violation.Waiver.Justification})
+	}
+	if violation.Baselined {
+		res.BaselineState = "unchanged"
+	}
+	return res
+}
+
+func toSarifPhysicalLocation(location violationTypes.Location) sarifPhysicalLocation {
+	res := sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: toArtifactURI(location.File)}}
+	if location.StartLine > 0 {
+		res.Region = &sarifRegion{StartLine: location.StartLine, EndLine: max(location.EndLine, location.StartLine)}
+		if len(location.Instruction) > 0 {
+			res.Region.Snippet = &sarifMessage{Text: location.Instruction}
+		}
+	}
+	return res
+}
+
+func toArtifactURI(file string) string {
+	if filepath.IsAbs(file) {
+		return (&url.URL{Scheme: "file", Path: filepath.ToSlash(file)}).String()
+	}
+	return toRepositoryPath(file)
+}
+
+func sarifLevel(severity string) string {
+	switch getLevel(severity) {
+	case levelError:
+		return "error"
+	case levelWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
@@ -0,0 +1,85 @@
+package reports_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/validator/reports"
+	"github.com/spf13/viper"
+)
+
+func TestWriteSarif(t *testing.T) {
+	viper.Set("docs_url", "https://docs.example.com/rules")
+	defer viper.Reset()
+
+	var buffer bytes.Buffer
+	if err := reports.Write(&buffer, reports.FormatSarif, reportViolations, reportRuleSet, reports.RunInfo{}); err != nil {
+		t.Fatalf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+	var actual struct {
+		Version string
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct {
+						Id              string
+						HelpUri         string
+						FullDescription struct{ Text string }
+					}
+				}
+			}
+			Invocations []struct {
+				ToolExecutionNotifications []struct {
+					Message        struct{ Text string }
+					AssociatedRule struct{ Index int }
+				}
+			}
+			Results []struct {
+				RuleId    string
+				RuleIndex int
+				Level     string
+				Message   struct{ Text string }
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct{ Uri string }
+						Region           *struct{ StartLine, EndLine int }
+					}
+				}
+				Suppressions []struct{ Kind, Justification string }
+			}
+		}
+	}
+	if err := json.Unmarshal(buffer.Bytes(), &actual); err != nil {
+		t.Fatalf("Invalid json: %s", err.Error())
+	}
+	if actual.Version != "2.1.0" || len(actual.Runs) != 1 {
+		t.Fatalf("Log mismatch: Expected 2.1.0 with 1 run Got %s with %d runs", actual.Version, len(actual.Runs))
+	}
+	run := actual.Runs[0]
+	if len(run.Tool.Driver.Rules) != 4 || run.Tool.Driver.Rules[0].HelpUri != "https://docs.example.com/rules#curl_fail" || run.Tool.Driver.Rules[0].FullDescription.Text != "Without -f curl ignores http errors" {
+		t.Errorf("Rule mismatch: Got %+v", run.Tool.Driver.Rules)
+	}
+	if len(run.Results) != 2 {
+		t.Fatalf("Result count mismatch: Expected 2 Got %d", len(run.Results))
+	}
+	result := run.Results[0]
+	if result.RuleId != "curl_fail" || result.Level != "error" || result.Message.Text != "curl is called without -f" {
+		t.Errorf("Result mismatch: Got %+v", result)
+	}
+	location := result.Locations[0].PhysicalLocation
+	if location.ArtifactLocation.Uri != "Dockerfile" || location.Region == nil || location.Region.StartLine != 3 || location.Region.EndLine != 4 {
+		t.Errorf("Location mismatch: Expected Dockerfile:3-4 Got %+v", location)
+	}
+	suppressed := run.Results[1]
+	if suppressed.RuleIndex != 1 || suppressed.Level != "note" || suppressed.Locations[0].PhysicalLocation.Region != nil {
+		t.Errorf("Result mismatch: Got %+v", suppressed)
+	}
+	if len(suppressed.Suppressions) != 1 || suppressed.Suppressions[0].Kind != "inSource" || suppressed.Suppressions[0].Justification != "interactive on purpose" {
+		t.Errorf("Suppression mismatch: Got %+v", suppressed.Suppressions)
+	}
+	notifications := run.Invocations[0].ToolExecutionNotifications
+	if len(notifications) != 1 || notifications[0].AssociatedRule.Index != 2 || notifications[0].Message.Text != "Rule could not be evaluated: FileNotFoundError: file not found" {
+		t.Errorf("Notification mismatch: Got %+v", notifications)
+	}
+}
@@ -0,0 +1,225 @@
+package reports
+
+import (
+	_ "embed"
+	"fmt"
+	htmlTemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	textTemplate "text/template"
+	"time"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/docs"
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/rules"
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/util"
+	violationTypes "github.com/coffeemakingtoaster/whale-watcher/pkg/validator/violations"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+//go:embed report.html.tmpl
+var htmlReportTemplate string
+
+//go:embed report.md.tmpl
+var markdownReportTemplate string
+
+// Data passed to the html and markdown templates, including custom ones passed via --template
+type TemplateData struct {
+	// Name of the ruleset
+	Name    string
+	Version string
+	Run     RunInfo
+	Summary JsonSummary
+	// Empty if docs_url is not set
+	DocUrl string
+	// Rules with violations (including suppressed, waived and baselined ones) grouped by category and target
+	Groups  []TemplateGroup
+	Errored []TemplateRule
+	Skipped []TemplateRule
+	Passed  []TemplateRule
+	// Rule ids of baseline entries that no longer occur
+	FixedSinceBaseline []string
+}
+
+type TemplateGroup struct {
+	Category string
+	Target   string
+	Rules    []TemplateRule
+}
+
+type TemplateRule struct {
+	Id              string
+	Description     string
+	LongDescription string
+	Severity        string
+	Category        string
+	Target          string
+	Source          string
+	Tags            []string
+	// Link to the rule in the docs, empty if docs_url is not set
+	URL string
+	// One of passed, violated, excepted, errored and skipped
+	Status     string
+	SkipReason string
+	Duration   time.Duration
+	Violations []violationTypes.Violation
+	// Set if the rule could not be evaluated
+	Error *violationTypes.RuleError
+}
+
+// Number of violations of the rule that count
+func (r TemplateRule) Failing() int {
+	count := 0
+	for _, violation := range r.Violations {
+		if !violation.IsExcepted() {
+			count++
+		}
+	}
+	return count
+}
+
+func NewTemplateData(violations violationTypes.Violations, ruleSet rules.RuleSet, info RunInfo) TemplateData {
+	report := NewJsonReport(violations, ruleSet, info)
+	data := TemplateData{
+		Name:               ruleSet.Name,
+		Version:            util.GetVersion(),
+		Run:                info,
+		Summary:            report.Summary,
+		DocUrl:             viper.GetString("docs_url"),
+		FixedSinceBaseline: report.FixedSinceBaseline,
+	}
+
+	rulesById := make(map[string]*rules.Rule)
+	for _, rule := range ruleSet.Rules {
+		rulesById[rule.Id] = rule
+	}
+	violationsByRule := make(map[string][]violationTypes.Violation)
+	for _, violation := range violations.Violations {
+		violationsByRule[violation.RuleId] = append(violationsByRule[violation.RuleId], violation)
+	}
+	errorsByRule := make(map[string]violationTypes.RuleError)
+	for _, ruleError := range violations.Errors {
+		errorsByRule[ruleError.RuleId] = ruleError
+	}
+
+	for _, result := range violations.Rules {
+		rule := TemplateRule{
+			Id:         result.RuleId,
+			Status:     result.Status,
+			SkipReason: result.SkipReason,
+			Duration:   result.Duration,
+			Violations: violationsByRule[result.RuleId],
+		}
+		if definition, ok := rulesById[result.RuleId]; ok {
+			rule.Description = definition.Description
+			rule.LongDescription = definition.LongDescription
+			rule.Severity = definition.Severity
+			rule.Category = definition.Category
+			rule.Target = definition.Target
+			rule.Source = definition.Source.String()
+			rule.Tags = definition.Tags
+		}
+		if len(data.DocUrl) > 0 {
+			u, err := violationTypes.GetDocURL(data.DocUrl, rule.Id)
+			if err != nil {
+				log.Warn().Err(err).Msg("Could not parse the docs url")
+			}
+			rule.URL = u
+		}
+		if ruleError, ok := errorsByRule[result.RuleId]; ok {
+			rule.Error = &ruleError
+		}
+
+		switch result.Status {
+		case violationTypes.RuleStatusViolated, violationTypes.RuleStatusExcepted:
+			data.addToGroup(rule)
+		case violationTypes.RuleStatusErrored:
+			data.Errored = append(data.Errored, rule)
+		case violationTypes.RuleStatusSkipped:
+			data.Skipped = append(data.Skipped, rule)
+		default:
+			data.Passed = append(data.Passed, rule)
+		}
+	}
+	slices.SortStableFunc(data.Groups, func(a, b TemplateGroup) int {
+		if a.Category != b.Category {
+			return strings.Compare(a.Category, b.Category)
+		}
+		return strings.Compare(a.Target, b.Target)
+	})
+	return data
+}
+
+func (d *TemplateData) addToGroup(rule TemplateRule) {
+	for i := range d.Groups {
+		if d.Groups[i].Category == rule.Category && d.Groups[i].Target == rule.Target {
+			d.Groups[i].Rules = append(d.Groups[i].Rules, rule)
+			return
+		}
+	}
+	d.Groups = append(d.Groups, TemplateGroup{Category: rule.Category, Target: rule.Target, Rules: []TemplateRule{rule}})
+}
+
+// Helpers available in the report templates
+func templateFuncs() map[string]any {
+	return map[string]any{
+		"stylesheet": func() htmlTemplate.CSS { return htmlTemplate.CSS(docs.Stylesheet) },
+		"milliseconds": func(duration time.Duration) string {
+			return fmt.Sprintf("%.1fms", toMilliseconds(duration))
+		},
+	}
+}
+
+// The executable form of a html/template or text/template
+type reportTemplate interface {
+	Execute(w io.Writer, data any) error
+}
+
+// Parse the embedded template of the format or the template file at path if it is set
+func parseTemplate(format, path string) (reportTemplate, error) {
+	name := "report"
+	content := htmlReportTemplate
+	if format == FormatMarkdown {
+		content = markdownReportTemplate
+	}
+	if len(path) > 0 {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		name = filepath.Base(path)
+		content = string(raw)
+	}
+	switch format {
+	case FormatHtml:
+		// html/template escapes the rule output so violations cannot inject markup
+		return htmlTemplate.New(name).Funcs(templateFuncs()).Parse(content)
+	case FormatMarkdown:
+		return textTemplate.New(name).Funcs(templateFuncs()).Parse(content)
+	default:
+		return nil, fmt.Errorf("Templates are only supported by the %s and %s formats (Got: %s)", FormatHtml, FormatMarkdown, format)
+	}
+}
+
+// Check that the template file can be used before running the rules
+func VerifyTemplate(format, path string) error {
+	if len(path) == 0 {
+		return nil
+	}
+	if _, err := parseTemplate(format, path); err != nil {
+		return fmt.Errorf("Invalid template %s: %w", path, err)
+	}
+	return nil
+}
+
+// Render the report using the template file at path, the embedded template of the format is used if path is empty
+func WriteTemplate(w io.Writer, format, path string, violations violationTypes.Violations, ruleSet rules.RuleSet, info RunInfo) error {
+	tmpl, err := parseTemplate(format, path)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, NewTemplateData(violations, ruleSet, info))
+}
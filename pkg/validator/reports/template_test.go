@@ -0,0 +1,107 @@
+package reports_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/rules"
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/validator/reports"
+	violationTypes "github.com/coffeemakingtoaster/whale-watcher/pkg/validator/violations"
+	"github.com/spf13/viper"
+)
+
+func TestWriteMarkdown(t *testing.T) {
+	viper.Set("docs_url", "https://docs.example.com/rules")
+	defer viper.Reset()
+
+	var buffer bytes.Buffer
+	if err := reports.Write(&buffer, reports.FormatMarkdown, reportViolations, reportRuleSet, reports.RunInfo{Dockerfile: "Dockerfile", ImageDigest: "sha256:abc"}); err != nil {
+		t.Fatalf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+	expected := []string{
+		"| Image digest | `sha256:abc` |",
+		"| Violations | 1 (1 failing, 0 fixable) |",
+		"- [curl_fail](https://docs.example.com/rules#curl_fail) (high): Use curl -f",
+		"    - At `./Dockerfile:3-4 (stage 0)`: `RUN curl example.com`",
+		"    - Suppressed: interactive on purpose",
+		"  - FileNotFoundError: file not found",
+		"- `os_only`: Target os is not allowed",
+	}
+	for _, line := range expected {
+		if !strings.Contains(buffer.String(), line) {
+			t.Errorf("Output mismatch: Expected line %q in %s", line, buffer.String())
+		}
+	}
+}
+
+func TestWriteHtmlEscapesOutput(t *testing.T) {
+	violations := violationTypes.Violations{
+		Violations: []violationTypes.Violation{{RuleId: "curl_fail", Severity: "high", Message: "<script>alert(1)</script>", Location: violationTypes.Location{File: "Dockerfile"}}},
+		Rules:      []violationTypes.RuleResult{{RuleId: "curl_fail", Status: violationTypes.RuleStatusViolated}},
+	}
+	var buffer bytes.Buffer
+	if err := reports.Write(&buffer, reports.FormatHtml, violations, reportRuleSet, reports.RunInfo{}); err != nil {
+		t.Fatalf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+	if strings.Contains(buffer.String(), "<script>") || !strings.Contains(buffer.String(), "&lt;script&gt;") {
+		t.Errorf("Output mismatch: Expected the message to be escaped")
+	}
+	if !strings.Contains(buffer.String(), "<style>") || !strings.Contains(buffer.String(), ".rule-entry") {
+		t.Errorf("Output mismatch: Expected the docs stylesheet to be inlined")
+	}
+}
+
+func TestWriteCustomTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.tmpl")
+	content := "{{ range .Groups }}{{ .Category }}/{{ .Target }}:{{ range .Rules }} {{ .Id }}={{ .Failing }}{{ end }};{{ end }} errored={{ len .Errored }}"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ruleSet := rules.RuleSet{Rules: []*rules.Rule{
+		{Id: "curl_fail", Category: "network", Target: "command"},
+		{Id: "apt_yes", Category: "apt", Target: "command"},
+		{Id: "broken", Category: "network", Target: "fs"},
+	}}
+	var buffer bytes.Buffer
+	if err := reports.WriteTemplate(&buffer, reports.FormatMarkdown, path, reportViolations, ruleSet, reports.RunInfo{}); err != nil {
+		t.Fatalf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+	expected := "apt/command: apt_yes=0;network/command: curl_fail=1; errored=1"
+	if buffer.String() != expected {
+		t.Errorf("Output mismatch: Expected %q Got %q", expected, buffer.String())
+	}
+}
+
+func TestVerifyTemplate(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.tmpl")
+	invalid := filepath.Join(dir, "invalid.tmpl")
+	if err := os.WriteFile(valid, []byte("{{ .Name }}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(invalid, []byte("{{ range .Groups }}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		format  string
+		path    string
+		isValid bool
+	}{
+		{reports.FormatHtml, "", true},
+		{reports.FormatSarif, "", true},
+		{reports.FormatHtml, valid, true},
+		{reports.FormatMarkdown, valid, true},
+		{reports.FormatSarif, valid, false},
+		{reports.FormatHtml, invalid, false},
+		{reports.FormatMarkdown, filepath.Join(dir, "missing.tmpl"), false},
+	}
+	for _, c := range cases {
+		err := reports.VerifyTemplate(c.format, c.path)
+		if (err == nil) != c.isValid {
+			t.Errorf("Validity mismatch for %s %s: Expected %v Got %v", c.format, c.path, c.isValid, err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package validator
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/coffeemakingtoaster/dockerfile-parser/pkg/util"
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/container"
+	"github.com/rs/zerolog/log"
+)
+
+// # whale-watcher:ignore <rule-id>[,<rule-id>...] [-- <reason>]
+// # whale-watcher:ignore-file <rule-id>[,<rule-id>...] [-- <reason>]
+var suppressionPattern = regexp.MustCompile(`^#\s*whale-watcher:(ignore|ignore-file)\s+(.*)$`)
+
+// Suppression of a rule via a pragma comment in the Dockerfile
+type Suppression struct {
+	RuleId string
+	Reason string
+	// Line of the pragma comment
+	PragmaLine int
+	// Line of the instruction the pragma applies to, 0 for file level pragmas
+	InstructionLine int
+}
+
+type Suppressions []Suppression
+
+// Read the suppression pragmas of the Dockerfile
+// Instruction pragmas apply to the next instruction, other comments and empty lines in between are skipped
+// Comments within an instruction (continuation lines and heredocs) are part of the instruction and never pragmas
+func ParseSuppressions(dockerfilePath string) Suppressions {
+	suppressions := Suppressions{}
+	if len(dockerfilePath) == 0 {
+		return suppressions
+	}
+	lines, err := util.ReadFileLines(dockerfilePath)
+	if err != nil {
+		log.Warn().Err(err).Str("dockerfile", dockerfilePath).Msg("Could not read Dockerfile for suppressions")
+		return suppressions
+	}
+
+	instructions := container.GetInstructionLocations(lines)
+	pending := []Suppression{}
+	next := 0
+	for i := 0; i < len(lines); i++ {
+		if next < len(instructions) && instructions[next].StartLine == i+1 {
+			for _, suppression := range pending {
+				suppression.InstructionLine = instructions[next].StartLine
+				suppressions = append(suppressions, suppression)
+			}
+			pending = pending[:0]
+			i = instructions[next].EndLine - 1
+			next++
+			continue
+		}
+		match := suppressionPattern.FindStringSubmatch(strings.TrimSpace(lines[i]))
+		if match == nil {
+			continue
+		}
+		for _, suppression := range parseSuppression(match[2], i+1) {
+			if match[1] == "ignore-file" {
+				suppressions = append(suppressions, suppression)
+			} else {
+				pending = append(pending, suppression)
+			}
+		}
+	}
+	for _, suppression := range pending {
+		log.Warn().Str("id", suppression.RuleId).Int("line", suppression.PragmaLine).Msg("Ignore pragma is not followed by an instruction")
+	}
+	return suppressions
+}
+
+func parseSuppression(content string, lineNumber int) []Suppression {
+	ids, reason, _ := strings.Cut(content, "--")
+	suppressions := []Suppression{}
+	for _, id := range strings.Split(ids, ",") {
+		id = strings.TrimSpace(id)
+		if len(id) == 0 {
+			continue
+		}
+		suppressions = append(suppressions, Suppression{RuleId: id, Reason: strings.TrimSpace(reason), PragmaLine: lineNumber})
+	}
+	if len(suppressions) == 0 {
+		log.Warn().Int("line", lineNumber).Msg("Ignore pragma without rule id")
+	}
+	return suppressions
+}
+
+// Find the suppression for a violation of the rule starting at the given line
+// Violations without a location (line 0) are suppressed by any pragma for the rule, most rules only assert and never locate their violations
+func (s Suppressions) Find(ruleId string, line int) (Suppression, bool) {
+	for _, suppression := range s {
+		if suppression.RuleId != ruleId {
+			continue
+		}
+		if line == 0 || suppression.InstructionLine == 0 || suppression.InstructionLine == line {
+			return suppression, true
+		}
+	}
+	return Suppression{}, false
+}
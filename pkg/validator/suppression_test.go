@@ -0,0 +1,125 @@
+package validator_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/rules"
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/runner"
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/validator"
+)
+
+const suppressedDockerfile = `# whale-watcher:ignore-file user_not_root -- runs as root by design
+FROM debian:trixie AS runtime
+
+# whale-watcher:ignore curl_always_use_f, apt_get_always_agree -- the mirror does not support it
+# some unrelated comment
+
+RUN apt-get update && \
+    curl example.com
+# whale-watcher:ignore no_reason
+RUN echo "hello"
+# whale-watcher:ignore dangling
+`
+
+func writeDockerfile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "Dockerfile")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseSuppressions(t *testing.T) {
+	expected := validator.Suppressions{
+		{RuleId: "user_not_root", Reason: "runs as root by design", PragmaLine: 1, InstructionLine: 0},
+		{RuleId: "curl_always_use_f", Reason: "the mirror does not support it", PragmaLine: 4, InstructionLine: 7},
+		{RuleId: "apt_get_always_agree", Reason: "the mirror does not support it", PragmaLine: 4, InstructionLine: 7},
+		{RuleId: "no_reason", Reason: "", PragmaLine: 9, InstructionLine: 10},
+	}
+	actual := validator.ParseSuppressions(writeDockerfile(t, suppressedDockerfile))
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Suppressions mismatch: Expected %v Got %v", expected, actual)
+	}
+}
+
+func TestParseSuppressionsWithinInstructions(t *testing.T) {
+	dockerfile := "# escape=`\n" + `FROM mcr.microsoft.com/windows/servercore AS build
+# whale-watcher:ignore run_rule
+RUN echo a ` + "`" + `
+    # whale-watcher:ignore inside_continuation
+    && echo b
+# whale-watcher:ignore copy_rule
+COPY <<EOF /app/notes
+# whale-watcher:ignore inside_heredoc
+EOF
+`
+	expected := validator.Suppressions{
+		{RuleId: "run_rule", Reason: "", PragmaLine: 3, InstructionLine: 4},
+		{RuleId: "copy_rule", Reason: "", PragmaLine: 7, InstructionLine: 8},
+	}
+	actual := validator.ParseSuppressions(writeDockerfile(t, dockerfile))
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Suppressions mismatch: Expected %v Got %v", expected, actual)
+	}
+}
+
+func TestValidateSuppressedViolation(t *testing.T) {
+	fixExecutionCount := 0
+	failingRunner := MockRunner{func(isFix bool) error {
+		if isFix {
+			fixExecutionCount++
+		}
+		return errors.New("No")
+	}}
+	input := rules.RuleSet{
+		Rules: []*rules.Rule{
+			// Violations without a location are suppressed by the instruction pragma of the rule
+			{Id: "curl_always_use_f", Target: "command", Severity: "high", Runner: failingRunner, FixInstruction: "abc"},
+			{Id: "user_not_root", Target: "command", Severity: "high", Runner: failingRunner},
+			{Id: "not_suppressed", Target: "command", Severity: "high", Runner: failingRunner},
+		},
+	}
+	actual := validator.ValidateRuleset(input, "", writeDockerfile(t, suppressedDockerfile), "")
+	if actual.ViolationCount != 1 || actual.FailingCount != 1 {
+		t.Errorf("Violation count mismatch: Expected 1 Got %d (failing %d)", actual.ViolationCount, actual.FailingCount)
+	}
+	if actual.SuppressedCount != 2 {
+		t.Errorf("Suppressed count mismatch: Expected 2 Got %d", actual.SuppressedCount)
+	}
+	if fixExecutionCount != 0 {
+		t.Errorf("Fix execution count mismatch: Expected 0 Got %d", fixExecutionCount)
+	}
+	if len(actual.Violations) != 3 {
+		t.Fatalf("Violations mismatch: Expected 3 Got %d", len(actual.Violations))
+	}
+	if !actual.Violations[0].Suppressed || actual.Violations[0].SuppressionReason != "the mirror does not support it" {
+		t.Errorf("Suppression mismatch: Expected suppressed with reason Got %v", actual.Violations[0])
+	}
+	if actual.Violations[2].Suppressed {
+		t.Errorf("Suppression mismatch: Expected %s to not be suppressed", actual.Violations[2].RuleId)
+	}
+}
+
+func TestValidateSuppressedAtInstruction(t *testing.T) {
+	input := rules.RuleSet{
+		Rules: []*rules.Rule{
+			// The pragma applies to the RUN instruction at line 7
+			{Id: "curl_always_use_f", Target: "command", Runner: LocatingRunner{[]runner.Location{{StartLine: 7, EndLine: 8, StageIndex: 0, StageName: "runtime"}}}},
+			{Id: "apt_get_always_agree", Target: "command", Runner: LocatingRunner{[]runner.Location{{StartLine: 10, EndLine: 10, StageIndex: 0, StageName: "runtime"}}}},
+		},
+	}
+	actual := validator.ValidateRuleset(input, "", writeDockerfile(t, suppressedDockerfile), "")
+	if actual.SuppressedCount != 1 || actual.ViolationCount != 1 {
+		t.Errorf("Count mismatch: Expected 1 suppressed and 1 violation Got %d suppressed %d violations", actual.SuppressedCount, actual.ViolationCount)
+	}
+	if !actual.Violations[0].Suppressed || actual.Violations[1].Suppressed {
+		t.Errorf("Suppression mismatch: Expected only the violation at the annotated instruction to be suppressed Got %v", actual.Violations)
+	}
+	if location := actual.Violations[1].Location; location.StartLine != 10 || location.StageName != "runtime" {
+		t.Errorf("Location mismatch: Expected line 10 in stage runtime Got %v", location)
+	}
+}
@@ -1,42 +1,252 @@
 package validator
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/coffeemakingtoaster/whale-watcher/pkg/config"
 	"github.com/coffeemakingtoaster/whale-watcher/pkg/rules"
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/runner"
 	violationTypes "github.com/coffeemakingtoaster/whale-watcher/pkg/validator/violations"
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/waivers"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
 )
 
+// State shared by the violations of all rules of a run
+type validation struct {
+	violations      violationTypes.Violations
+	failThreshold   int
+	suppressions    Suppressions
+	waiverFile      waivers.WaiverFile
+	waiverTarget    waivers.Target
+	now             time.Time
+	baselineMatches *baselineMatcher
+}
+
 func ValidateRuleset(ruleset rules.RuleSet, ociTarPath, dockerFilePath string, dockerTarPath string) violationTypes.Violations {
-	violations := violationTypes.Violations{}
+	waiverFile, err := waivers.LoadConfigured()
+	if err != nil {
+		log.Error().Err(err).Msg("Could not load waivers, continuing without waivers")
+	}
+	displayPath := getDisplayDockerfilePath(dockerFilePath)
+	baseline, err := loadConfiguredBaseline()
+	if err != nil {
+		log.Error().Err(err).Msg("Could not load baseline, continuing without baseline")
+	}
+	v := validation{
+		failThreshold:   getFailThreshold(),
+		suppressions:    ParseSuppressions(dockerFilePath),
+		waiverFile:      waiverFile,
+		waiverTarget:    waivers.Target{Dockerfile: displayPath, Image: viper.GetString("target.image")},
+		now:             time.Now(),
+		baselineMatches: newBaselineMatcher(baseline),
+	}
+	checkedIds := make(map[string]bool)
 	for _, rule := range ruleset.Rules {
 		if !config.AllowsTarget(rule.Target) {
 			log.Info().Str("id", rule.Id).Msg("Skipped because target is disallowed")
+			v.addResult(rule, violationTypes.RuleResult{Status: violationTypes.RuleStatusSkipped, SkipReason: fmt.Sprintf("Target %s is not allowed", rule.Target)})
 			continue
 		}
-		violations.CheckedCount++
-		success, fix := rule.Validate(ociTarPath, dockerFilePath, dockerTarPath)
-		if success {
+		v.violations.CheckedCount++
+		started := time.Now()
+		outcome, info := rule.Validate(ociTarPath, dockerFilePath, dockerTarPath)
+		result := violationTypes.RuleResult{Duration: time.Since(started), PopulateDuration: info.Timings.Populate, SetupDuration: info.Timings.Setup}
+		// Errored rules are neither fixed nor compared against the baseline, their baseline entries may still occur
+		if outcome == rules.OutcomeErrored {
+			v.addError(rule, info)
+			result.Status = violationTypes.RuleStatusErrored
+			v.addResult(rule, result)
+			continue
+		}
+		checkedIds[rule.Id] = true
+		if outcome == rules.OutcomePassed {
+			result.Status = violationTypes.RuleStatusPassed
+			v.addResult(rule, result)
+			continue
+		}
+		fixable := (info.Fix != "" || rule.FixInstruction != "") && !viper.GetBool("no_fix")
+		status := violationTypes.RuleStatusExcepted
+		// The fix instruction covers every finding of the rule, so it is only run once
+		var toFix []int
+		for _, violation := range buildViolations(rule, displayPath, info) {
+			if fixable {
+				violation.Fix = info.Fix
+			}
+			if !v.add(violation, fixable) {
+				continue
+			}
+			status = violationTypes.RuleStatusViolated
+			if fixable {
+				toFix = append(toFix, len(v.violations.Violations)-1)
+			}
+		}
+		result.Status = status
+		if len(toFix) == 0 {
+			v.addResult(rule, result)
 			continue
 		}
-		log.Info().Str("id", rule.Id).Msg("Violation detected")
-		violations.ViolationCount++
-		violation := violationTypes.Violation{
-			RuleId:      rule.Id,
-			Description: rule.Description,
-		}
-		if (fix.Fix != "" || rule.FixInstruction != "") && !viper.GetBool("nofix") {
-			violations.FixableCount++
-			violation.Fix = fix.Fix
-			err := rule.PerformFix()
-			if err != nil {
-				violation.AutoFixed = false
+		fixStarted := time.Now()
+		err := rule.PerformFix()
+		result.FixDuration = time.Since(fixStarted)
+		v.addResult(rule, result)
+		for _, i := range toFix {
+			v.violations.Violations[i].AutoFixed = err == nil
+		}
+	}
+	if baseline != nil {
+		for _, entry := range v.baselineMatches.unmatched(checkedIds) {
+			v.violations.FixedSinceBaseline = append(v.violations.FixedSinceBaseline, entry.RuleId)
+		}
+	}
+	return v.violations
+}
+
+func (v *validation) addResult(rule *rules.Rule, result violationTypes.RuleResult) {
+	result.RuleId = rule.Id
+	result.Target = rule.Target
+	v.violations.Rules = append(v.violations.Rules, result)
+}
+
+func (v *validation) addError(rule *rules.Rule, info rules.ViolationInfo) {
+	ruleError := violationTypes.RuleError{
+		RuleId:      rule.Id,
+		Description: rule.Description,
+		Source:      rule.Source.String(),
+		Message:     info.Details,
+	}
+	if info.Exception != nil {
+		ruleError.Message = info.Exception.Message
+		ruleError.Exception = &violationTypes.Exception{Type: info.Exception.Type, Traceback: strings.TrimSpace(info.Exception.Traceback)}
+	}
+	log.Info().Str("id", rule.Id).Str("error", ruleError.Detail()).Msg("Rule could not be evaluated")
+	v.violations.ErrorCount++
+	v.violations.Errors = append(v.violations.Errors, ruleError)
+}
+
+// Add the violation and return whether it counts
+func (v *validation) add(violation violationTypes.Violation, fixable bool) bool {
+	// Suppressed violations are reported but neither counted nor fixed
+	if suppression, ok := v.suppressions.Find(violation.RuleId, violation.Location.StartLine); ok {
+		log.Info().Str("id", violation.RuleId).Str("reason", suppression.Reason).Int("line", suppression.PragmaLine).Msg("Violation suppressed")
+		v.violations.SuppressedCount++
+		violation.Suppressed = true
+		violation.SuppressionReason = suppression.Reason
+		v.violations.Violations = append(v.violations.Violations, violation)
+		return false
+	}
+	if waiver, ok := v.waiverFile.Find(violation.RuleId, v.waiverTarget, v.now); ok {
+		violation.Waiver = &violationTypes.WaiverInfo{
+			Owner:         waiver.Owner,
+			Justification: waiver.Justification,
+			Expires:       waiver.Expires,
+			Expired:       waiver.IsExpired(v.now),
+		}
+		// Active waivers are reported but neither counted nor fixed
+		if !violation.Waiver.Expired {
+			log.Info().Str("id", violation.RuleId).Str("owner", waiver.Owner).Str("expires", waiver.Expires).Msg("Violation waived")
+			v.violations.WaivedCount++
+			v.violations.Violations = append(v.violations.Violations, violation)
+			return false
+		}
+		log.Warn().Str("id", violation.RuleId).Str("owner", waiver.Owner).Str("expires", waiver.Expires).Msg("Waiver expired")
+	}
+	// Baselined violations are reported but neither counted nor fixed, an expired waiver cannot be baselined away
+	if violation.Waiver == nil && v.baselineMatches.match(violation) {
+		log.Info().Str("id", violation.RuleId).Msg("Violation is part of the baseline")
+		v.violations.BaselinedCount++
+		violation.Baselined = true
+		v.violations.Violations = append(v.violations.Violations, violation)
+		return false
+	}
+	log.Info().Str("id", violation.RuleId).Str("severity", violation.Severity).Msg("Violation detected")
+	v.violations.ViolationCount++
+	// Expired waivers fail the run regardless of the severity
+	if rules.SeverityRank(violation.Severity) >= v.failThreshold || violation.Waiver != nil {
+		v.violations.FailingCount++
+	}
+	if fixable {
+		v.violations.FixableCount++
+		violation.Fixable = true
+	}
+	v.violations.Violations = append(v.violations.Violations, violation)
+	return true
+}
+
+// One violation per reported finding, a rule that failed without reporting is a single violation
+func buildViolations(rule *rules.Rule, displayPath string, info rules.ViolationInfo) []violationTypes.Violation {
+	base := violationTypes.Violation{
+		RuleId:      rule.Id,
+		Description: rule.Description,
+		Severity:    rule.Severity,
+		Source:      rule.Source.String(),
+		Location:    getLocation(displayPath, info),
+	}
+	if len(info.Reports) == 0 {
+		if info.Exception != nil {
+			base.Message = info.Exception.Message
+			base.Exception = &violationTypes.Exception{Type: info.Exception.Type, Traceback: strings.TrimSpace(info.Exception.Traceback)}
+		}
+		return []violationTypes.Violation{base}
+	}
+	violations := make([]violationTypes.Violation, 0, len(info.Reports))
+	for _, report := range info.Reports {
+		violation := base
+		violation.Message = report.Message
+		if len(report.Severity) > 0 {
+			if err := rules.VerifySeverity(report.Severity); err != nil {
+				log.Warn().Str("id", rule.Id).Err(err).Msg("Reported severity is invalid, using the severity of the rule")
 			} else {
-				violation.AutoFixed = true
+				violation.Severity = strings.ToLower(report.Severity)
 			}
 		}
-		violations.Violations = append(violations.Violations, violation)
+		if report.Location != nil {
+			violation.Location = toLocation(displayPath, *report.Location)
+		}
+		violations = append(violations, violation)
 	}
 	return violations
 }
+
+// Remote Dockerfiles are checked out to a temporary location, so the path in the repository is reported and matched against waivers
+func getDisplayDockerfilePath(dockerFilePath string) string {
+	if len(viper.GetString("target.repository")) > 0 {
+		return viper.GetString("target.dockerfile")
+	}
+	return dockerFilePath
+}
+
+// The first location attached by the rule, a violation without one only points to the Dockerfile
+func getLocation(file string, info rules.ViolationInfo) violationTypes.Location {
+	location := violationTypes.Location{File: file, StageIndex: -1}
+	if len(info.Locations) == 0 {
+		return location
+	}
+	if len(info.Locations) > 1 {
+		log.Debug().Int("locations", len(info.Locations)).Msg("Rule attached multiple locations, only the first one is reported")
+	}
+	return toLocation(file, info.Locations[0])
+}
+
+func toLocation(file string, location runner.Location) violationTypes.Location {
+	return violationTypes.Location{
+		File:        file,
+		StartLine:   location.StartLine,
+		EndLine:     location.EndLine,
+		StageIndex:  location.StageIndex,
+		StageName:   location.StageName,
+		Instruction: location.Instruction,
+	}
+}
+
+// Rank of the lowest severity that fails the run
+// Without a configured threshold every violation fails the run
+func getFailThreshold() int {
+	failOn := viper.GetString("fail_on")
+	if len(failOn) == 0 {
+		return 0
+	}
+	return rules.SeverityRank(failOn)
+}
@@ -2,11 +2,15 @@ package validator_test
 
 import (
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/coffeemakingtoaster/whale-watcher/pkg/rules"
 	"github.com/coffeemakingtoaster/whale-watcher/pkg/runner"
 	"github.com/coffeemakingtoaster/whale-watcher/pkg/validator"
+	violationTypes "github.com/coffeemakingtoaster/whale-watcher/pkg/validator/violations"
 	"github.com/spf13/viper"
 )
 
@@ -14,9 +18,39 @@ type MockRunner struct {
 	callback func(bool) error
 }
 
-func (mr MockRunner) Run(runner.TemplateData, string, int) error { return mr.callback(false) }
-func (mr MockRunner) RunFix(command string)                      { mr.callback(true) }
-func (mr MockRunner) ToString() string                           { return "" }
+// Errors returned by the callback are failed assertions
+func (mr MockRunner) Run(runner.TemplateData, string, int) (runner.RunResult, error) {
+	err := mr.callback(false)
+	if err != nil {
+		return runner.RunResult{Exception: &runner.Exception{Type: "AssertionError"}}, err
+	}
+	return runner.RunResult{}, nil
+}
+func (mr MockRunner) RunFix(string, map[string]any) { mr.callback(true) }
+func (mr MockRunner) ToString() string              { return "" }
+
+// Fails and attaches the given locations
+type LocatingRunner struct {
+	locations []runner.Location
+}
+
+func (lr LocatingRunner) Run(runner.TemplateData, string, int) (runner.RunResult, error) {
+	return runner.RunResult{Locations: lr.locations, Exception: &runner.Exception{Type: "AssertionError"}}, errors.New("No")
+}
+func (lr LocatingRunner) RunFix(string, map[string]any) {}
+func (lr LocatingRunner) ToString() string              { return "" }
+
+// Passes every assertion but reports the given findings
+type ReportingRunner struct {
+	reports  []runner.Report
+	fixCount *int
+}
+
+func (rr ReportingRunner) Run(runner.TemplateData, string, int) (runner.RunResult, error) {
+	return runner.RunResult{Reports: rr.reports}, nil
+}
+func (rr ReportingRunner) RunFix(string, map[string]any) { *rr.fixCount++ }
+func (rr ReportingRunner) ToString() string              { return "" }
 
 func TestValidateFullValidRuleset(t *testing.T) {
 	executionCount := 0
@@ -218,10 +252,13 @@ func TestLimitTargetValidation(t *testing.T) {
 	if executionCount != 2 {
 		t.Errorf("Execution count mismatch: Expected 2 Got %d", executionCount)
 	}
+	if len(actual.Rules) != 3 || actual.Rules[0].Status != violationTypes.RuleStatusPassed || actual.Rules[2].Status != violationTypes.RuleStatusSkipped {
+		t.Errorf("Rule result mismatch: Expected 2 passed 1 skipped Got %+v", actual.Rules)
+	}
 }
 
 func TestValidateNoFixExecution(t *testing.T) {
-	viper.Set("WHALE_WATCHER_NO_FIX", "true")
+	viper.Set("no_fix", true)
 	defer viper.Reset()
 
 	runExecutionCount := 0
@@ -276,3 +313,246 @@ func TestValidateNoFixExecution(t *testing.T) {
 		t.Errorf("fix execution count mismatch: Expected 0 Got %d", fixExecutionCount)
 	}
 }
+
+func TestValidateFailThreshold(t *testing.T) {
+	viper.Set("fail_on", "high")
+	defer viper.Reset()
+
+	failingRunner := MockRunner{func(_ bool) error {
+		return errors.New("No")
+	},
+	}
+	input := rules.RuleSet{
+		Rules: []*rules.Rule{
+			{
+				Category:    "Negative",
+				Instruction: "abc",
+				Description: "def",
+				Id:          "low severity",
+				Target:      "fs",
+				Severity:    "low",
+				Runner:      failingRunner,
+			},
+			{
+				Category:    "Negative",
+				Instruction: "abc",
+				Description: "def",
+				Id:          "critical severity",
+				Target:      "fs",
+				Severity:    "critical",
+				Runner:      failingRunner,
+			},
+			{
+				Category:    "Negative",
+				Instruction: "abc",
+				Description: "def",
+				Id:          "default severity",
+				Target:      "fs",
+				Runner:      failingRunner,
+			},
+		},
+	}
+	actual := validator.ValidateRuleset(input, "", "", "")
+	if actual.ViolationCount != 3 {
+		t.Errorf("violation count mismatch: Expected 3 Got %d", actual.ViolationCount)
+	}
+	if actual.FailingCount != 1 {
+		t.Errorf("failing count mismatch: Expected 1 Got %d", actual.FailingCount)
+	}
+	if actual.Violations[1].Severity != "critical" {
+		t.Errorf("severity mismatch: Expected critical Got %s", actual.Violations[1].Severity)
+	}
+}
+
+func TestValidateWaivedViolation(t *testing.T) {
+	waiverPath := filepath.Join(t.TempDir(), "waivers.yaml")
+	waiverContent := `waivers:
+  - rule: waived
+    justification: Accepted risk
+    owner: security
+    expires: 2999-12-31
+  - rule: expired
+    justification: Accepted risk
+    owner: security
+    expires: 2000-01-01
+`
+	if err := os.WriteFile(waiverPath, []byte(waiverContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("waivers", waiverPath)
+	viper.Set("fail_on", "critical")
+	defer viper.Reset()
+
+	failingRunner := MockRunner{func(_ bool) error { return errors.New("No") }}
+	input := rules.RuleSet{
+		Rules: []*rules.Rule{
+			{Id: "waived", Target: "command", Severity: "high", Runner: failingRunner},
+			{Id: "expired", Target: "command", Severity: "low", Runner: failingRunner},
+		},
+	}
+	actual := validator.ValidateRuleset(input, "", "", "")
+	if actual.WaivedCount != 1 {
+		t.Errorf("Waived count mismatch: Expected 1 Got %d", actual.WaivedCount)
+	}
+	// Expired waivers fail the run even below the threshold
+	if actual.ViolationCount != 1 || actual.FailingCount != 1 {
+		t.Errorf("Violation count mismatch: Expected 1 failing Got %d (failing %d)", actual.ViolationCount, actual.FailingCount)
+	}
+	if !actual.Violations[0].IsExcepted() || actual.Violations[0].Waiver.Owner != "security" {
+		t.Errorf("Waiver mismatch: Expected waived violation Got %v", actual.Violations[0])
+	}
+	if actual.Violations[1].IsExcepted() || !actual.Violations[1].Waiver.Expired {
+		t.Errorf("Waiver mismatch: Expected expired waiver Got %v", actual.Violations[1].Waiver)
+	}
+}
+
+func TestValidateReportedFindings(t *testing.T) {
+	viper.Set("fail_on", "high")
+	defer viper.Reset()
+
+	fixCount := 0
+	reportingRunner := ReportingRunner{
+		reports: []runner.Report{
+			{Message: "curl without -f", Location: &runner.Location{StartLine: 3, EndLine: 3, StageIndex: 0, Instruction: "RUN curl a"}},
+			{Message: "curl without -f", Severity: "critical", Location: &runner.Location{StartLine: 5, EndLine: 6, StageIndex: 0, Instruction: "RUN curl b"}},
+			{Message: "invalid severity", Severity: "urgent"},
+		},
+		fixCount: &fixCount,
+	}
+	input := rules.RuleSet{
+		Rules: []*rules.Rule{
+			{Id: "reporting", Target: "command", Severity: "low", Runner: reportingRunner, FixInstruction: "fix"},
+		},
+	}
+	actual := validator.ValidateRuleset(input, "", "Dockerfile", "")
+	if actual.ViolationCount != 3 || len(actual.Violations) != 3 {
+		t.Fatalf("Violation count mismatch: Expected 3 Got %d (%d reported)", actual.ViolationCount, len(actual.Violations))
+	}
+	if actual.FailingCount != 1 {
+		t.Errorf("Failing count mismatch: Expected 1 Got %d", actual.FailingCount)
+	}
+	// The fix instruction covers all findings of the rule
+	if fixCount != 1 || actual.FixableCount != 3 {
+		t.Errorf("Fix mismatch: Expected 1 fix run for 3 fixable violations Got %d runs for %d", fixCount, actual.FixableCount)
+	}
+	expected := []struct {
+		severity string
+		line     int
+	}{{"low", 3}, {"critical", 5}, {"low", 0}}
+	for i, e := range expected {
+		violation := actual.Violations[i]
+		if violation.Severity != e.severity || violation.Location.StartLine != e.line || !violation.AutoFixed {
+			t.Errorf("Violation %d mismatch: Expected %s at line %d Got %+v", i, e.severity, e.line, violation)
+		}
+	}
+	if actual.Violations[0].Message != "curl without -f" {
+		t.Errorf("Message mismatch: Expected 'curl without -f' Got '%s'", actual.Violations[0].Message)
+	}
+}
+
+// Fails with the given exception
+type RaisingRunner struct {
+	exception runner.Exception
+}
+
+func (rr RaisingRunner) Run(runner.TemplateData, string, int) (runner.RunResult, error) {
+	return runner.RunResult{Exception: &rr.exception}, errors.New("exit status 1")
+}
+func (rr RaisingRunner) RunFix(string, map[string]any) {}
+func (rr RaisingRunner) ToString() string              { return "" }
+
+func TestValidateExceptionDetails(t *testing.T) {
+	input := rules.RuleSet{
+		Rules: []*rules.Rule{
+			{Id: "assertion", Target: "command", Runner: RaisingRunner{runner.Exception{Type: "AssertionError", Message: "curl needs -f", Traceback: "Traceback\nAssertionError: curl needs -f\n"}}},
+			{Id: "key", Target: "command", Runner: RaisingRunner{runner.Exception{Type: "KeyError", Message: "'flag'"}}},
+			{Id: "bare", Target: "command", Runner: RaisingRunner{runner.Exception{Type: "AssertionError"}}},
+		},
+	}
+	actual := validator.ValidateRuleset(input, "", "", "")
+	if len(actual.Violations) != 2 || len(actual.Errors) != 1 {
+		t.Fatalf("Count mismatch: Expected 2 violations 1 error Got %d violations %d errors", len(actual.Violations), len(actual.Errors))
+	}
+	expected := []string{"curl needs -f", ""}
+	for i, e := range expected {
+		if actual.Violations[i].Detail() != e {
+			t.Errorf("Detail mismatch: Expected '%s' Got '%s'", e, actual.Violations[i].Detail())
+		}
+	}
+	if actual.Errors[0].Detail() != "KeyError: 'flag'" {
+		t.Errorf("Detail mismatch: Expected 'KeyError: 'flag'' Got '%s'", actual.Errors[0].Detail())
+	}
+	if actual.Violations[0].Exception == nil || actual.Violations[0].Exception.Traceback != "Traceback\nAssertionError: curl needs -f" {
+		t.Errorf("Exception mismatch: Expected trimmed traceback Got %+v", actual.Violations[0].Exception)
+	}
+}
+
+func TestValidateRuleErrors(t *testing.T) {
+	input := rules.RuleSet{
+		Rules: []*rules.Rule{
+			{Id: "panicking", Target: "command", FixInstruction: "fix", Runner: RaisingRunner{runner.Exception{Message: "panic: file not found"}}},
+			{Id: "crashing", Target: "command", FixInstruction: "fix", Runner: CrashingRunner{}},
+			{Id: "violated", Target: "command", Runner: MockRunner{func(_ bool) error { return errors.New("No") }}},
+		},
+	}
+	actual := validator.ValidateRuleset(input, "", "", "")
+	if actual.ErrorCount != 2 || len(actual.Errors) != 2 {
+		t.Fatalf("Error count mismatch: Expected 2 Got %d (%d reported)", actual.ErrorCount, len(actual.Errors))
+	}
+	// Errors must neither mask violations nor be counted as such
+	if actual.ViolationCount != 1 || actual.Violations[0].RuleId != "violated" {
+		t.Errorf("Violation mismatch: Expected only violated Got %d %+v", actual.ViolationCount, actual.Violations)
+	}
+	if actual.FixableCount != 0 {
+		t.Errorf("Fixable count mismatch: Expected 0 Got %d", actual.FixableCount)
+	}
+	if actual.Errors[0].Detail() != "panic: file not found" || actual.Errors[1].Detail() != "exit status 127" {
+		t.Errorf("Detail mismatch: Got '%s' and '%s'", actual.Errors[0].Detail(), actual.Errors[1].Detail())
+	}
+}
+
+// Fails without an exception, e.g. because the interpreter could not be started
+type CrashingRunner struct{}
+
+func (cr CrashingRunner) Run(runner.TemplateData, string, int) (runner.RunResult, error) {
+	return runner.RunResult{}, errors.New("exit status 127")
+}
+func (cr CrashingRunner) RunFix(string, map[string]any) {}
+func (cr CrashingRunner) ToString() string              { return "" }
+
+// Reports the given timings, fails if a finding is passed
+type TimedRunner struct {
+	timings runner.Timings
+	reports []runner.Report
+}
+
+func (tr TimedRunner) Run(runner.TemplateData, string, int) (runner.RunResult, error) {
+	return runner.RunResult{Timings: tr.timings, Reports: tr.reports}, nil
+}
+func (tr TimedRunner) RunFix(string, map[string]any) { time.Sleep(time.Millisecond) }
+func (tr TimedRunner) ToString() string              { return "" }
+
+func TestValidateRuleTimings(t *testing.T) {
+	timings := runner.Timings{Populate: time.Second, Setup: 2 * time.Second}
+	input := rules.RuleSet{
+		Rules: []*rules.Rule{
+			{Id: "passing", Target: "command", Runner: TimedRunner{timings: timings}},
+			{Id: "fixed", Target: "fs", FixInstruction: "fix", Runner: TimedRunner{timings: timings, reports: []runner.Report{{Message: "finding"}}}},
+		},
+	}
+	actual := validator.ValidateRuleset(input, "", "", "")
+	if len(actual.Rules) != 2 {
+		t.Fatalf("Rule result mismatch: Expected 2 Got %d", len(actual.Rules))
+	}
+	for _, result := range actual.Rules {
+		if result.PopulateDuration != time.Second || result.SetupDuration != 2*time.Second {
+			t.Errorf("Timing mismatch for %s: Got %+v", result.RuleId, result)
+		}
+	}
+	if actual.Rules[0].Target != "command" || actual.Rules[1].Target != "fs" {
+		t.Errorf("Target mismatch: Got %s and %s", actual.Rules[0].Target, actual.Rules[1].Target)
+	}
+	if actual.Rules[0].FixDuration != 0 || actual.Rules[1].FixDuration < time.Millisecond {
+		t.Errorf("Fix duration mismatch: Expected only the fixed rule to have one Got %s and %s", actual.Rules[0].FixDuration, actual.Rules[1].FixDuration)
+	}
+}
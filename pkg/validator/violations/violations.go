@@ -2,9 +2,16 @@ package violations
 
 import (
 	"bytes"
+	"cmp"
+	"crypto/sha256"
 	_ "embed"
+	"fmt"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"text/template"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
@@ -14,74 +21,322 @@ type Violations struct {
 	CheckedCount   int
 	ViolationCount int
 	FixableCount   int
-	Violations     []Violation
+	// Violations with a severity at or above the fail threshold
+	FailingCount int
+	// Violations suppressed via pragma, these are not part of the other counts
+	SuppressedCount int
+	// Violations covered by an active waiver, these are not part of the other counts
+	WaivedCount int
+	// Violations that are part of the baseline, these are not part of the other counts
+	BaselinedCount int
+	// Rules that could not be evaluated, these are neither violations nor part of the other counts
+	ErrorCount int
+	// Rule ids of baseline entries that no longer occur
+	FixedSinceBaseline []string
+	Violations         []Violation
+	Errors             []RuleError
+	// Outcome of every rule of the ruleset in order
+	Rules []RuleResult
+}
+
+const (
+	RuleStatusPassed   = "passed"
+	RuleStatusViolated = "violated"
+	// Every violation of the rule was suppressed, waived or baselined
+	RuleStatusExcepted = "excepted"
+	RuleStatusErrored  = "errored"
+	// The rule was not run, e.g. because its target is not allowed
+	RuleStatusSkipped = "skipped"
+)
+
+type RuleResult struct {
+	RuleId string
+	Target string
+	Status string
+	// Why the rule was skipped
+	SkipReason string
+	// Time it took to evaluate the rule, 0 for skipped rules
+	Duration time.Duration
+	// Parts of Duration spent populating the working directory and loading the Dockerfile and image in the utils
+	PopulateDuration time.Duration
+	SetupDuration    time.Duration
+	// Time it took to run the fix instruction, 0 if no fix was run
+	FixDuration time.Duration
+}
+
+// Where in the Dockerfile the violation is
+type Location struct {
+	File string
+	// 1 based, inclusive. 0 if the rule did not attach a location
+	StartLine int
+	EndLine   int
+	// -1 for instructions before the first FROM
+	StageIndex  int
+	StageName   string
+	Instruction string
+}
+
+// Short form, e.g. Dockerfile:3-5 (stage build)
+func (l Location) String() string {
+	res := l.File
+	if l.StartLine > 0 {
+		res = fmt.Sprintf("%s:%d", res, l.StartLine)
+		if l.EndLine > l.StartLine {
+			res = fmt.Sprintf("%s-%d", res, l.EndLine)
+		}
+		if len(l.StageName) > 0 {
+			res = fmt.Sprintf("%s (stage %s)", res, l.StageName)
+		} else if l.StageIndex >= 0 {
+			res = fmt.Sprintf("%s (stage %d)", res, l.StageIndex)
+		}
+	}
+	return res
+}
+
+// Waiver that matched a violation
+type WaiverInfo struct {
+	Owner         string
+	Justification string
+	Expires       string
+	Expired       bool
+}
+
+// Exception that failed the rule, failed assertions are an AssertionError
+type Exception struct {
+	Type      string
+	Traceback string
 }
 
 type Violation struct {
 	RuleId      string
 	Description string
-	Fix         string
-	AutoFixed   bool
+	Severity    string
+	// Ruleset the violated rule was defined in
+	Source string
+	// Message passed to report() or of the exception that failed the rule
+	Message string
+	// Set if the rule failed due to an exception rather than reports
+	Exception *Exception
+	Location  Location
+	Fix       string
+	// Set if a fix instruction was available, AutoFixed is only set if it ran successfully
+	Fixable   bool
+	AutoFixed bool
+	// Set if the violation was suppressed via a pragma in the Dockerfile
+	Suppressed        bool
+	SuppressionReason string
+	// Set if a waiver matched the violation, the violation only counts if the waiver is expired
+	Waiver *WaiverInfo
+	// Set if the violation is part of the baseline
+	Baselined bool
+}
+
+// Rule that could not be evaluated, e.g. because a util panicked or the interpreter crashed
+type RuleError struct {
+	RuleId      string
+	Description string
+	Source      string
+	Message     string
+	// Nil if the rule could not be started
+	Exception *Exception
+}
+
+// Message of the error prefixed with the exception type
+func (e RuleError) Detail() string {
+	if e.Exception == nil || len(e.Exception.Type) == 0 {
+		return e.Message
+	}
+	if len(e.Message) == 0 {
+		return e.Exception.Type
+	}
+	return fmt.Sprintf("%s: %s", e.Exception.Type, e.Message)
+}
+
+// Message of the violation, exceptions other than failed assertions are prefixed with their type
+func (v Violation) Detail() string {
+	if v.Exception == nil || len(v.Exception.Type) == 0 || v.Exception.Type == "AssertionError" {
+		return v.Message
+	}
+	if len(v.Message) == 0 {
+		return v.Exception.Type
+	}
+	return fmt.Sprintf("%s: %s", v.Exception.Type, v.Message)
+}
+
+// Reported but not counted as violation
+func (v Violation) IsExcepted() bool {
+	return v.Suppressed || v.Baselined || (v.Waiver != nil && !v.Waiver.Expired)
+}
+
+// Stable identifier of the violation used for baselines
+// Covers the rule and the offending instruction but not its line, so unrelated changes to the Dockerfile keep the fingerprint
+func (v Violation) Fingerprint() string {
+	instruction := strings.Join(strings.Fields(strings.ReplaceAll(v.Location.Instruction, "\\\n", " ")), " ")
+	if len(instruction) == 0 {
+		return fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(v.RuleId)))
+	}
+	return fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(v.RuleId+"\n"+instruction)))
 }
 
 type templateViolation struct {
 	RuleId      string
 	Description string
+	Severity    string
+	Source      string
 	URL         string
+	Reason      string
+	Message     string
+	Waiver      *WaiverInfo
+	Location    string
+	Instruction string
+	Fixable     bool
+	AutoFixed   bool
+	Fingerprint string
+	// Exception type if the rule failed due to an exception other than a failed assertion
+	ExceptionType string
+}
+
+// Context of the run shown in the pull request, all fields are optional
+type PullRequestInfo struct {
+	// Dockerfile path, the path in the repository for remote Dockerfiles
+	Dockerfile  string
+	Image       string
+	ImageDigest string
+	// Location of the ruleset as passed to validate
+	RuleSet        string
+	RuleSetName    string
+	RuleSetVersion string
+	// Version of whale watcher
+	Version string
+	// Unified diff of the fixes that were applied to the Dockerfile
+	Diff string
 }
 
 type templateContent struct {
-	Fixed    []templateViolation
-	Detected []templateViolation
-	DocUrl   string
+	PullRequestInfo
+	Fixed      []templateViolation
+	Detected   []templateViolation
+	Suppressed []templateViolation
+	Waived     []templateViolation
+	Baselined  []templateViolation
+	Errored    []templateViolation
+	// Every violation in the order of the rules, including excepted ones
+	Violations []templateViolation
+	DocUrl     string
 }
 
+// Title used if no title template is configured
+const defaultPullRequestTitle = "Autofixes"
+
 //go:embed pr_content.tmpl
 var prTemplate string
 
-func (v *Violations) BuildDescriptionMarkdown() string {
-	var fixed []templateViolation
-	var detected []templateViolation
+// Parse the configured title and body templates, the embedded ones are used if none are configured
+// Custom body templates can use the list-entry template of the embedded one
+func parsePullRequestTemplates() (*template.Template, *template.Template, error) {
+	titleTemplate, err := template.New("title").Parse(cmp.Or(viper.GetString("pull_request.title"), defaultPullRequestTitle))
+	if err != nil {
+		return nil, nil, fmt.Errorf("Invalid pull request title template: %w", err)
+	}
+	bodyTemplate, err := template.New("site").Parse(prTemplate)
+	if err != nil {
+		return nil, nil, err
+	}
+	if path := viper.GetString("pull_request.template"); len(path) > 0 {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Could not read pull request template: %w", err)
+		}
+		bodyTemplate, err = bodyTemplate.New(filepath.Base(path)).Parse(string(content))
+		if err != nil {
+			return nil, nil, fmt.Errorf("Invalid pull request template %s: %w", path, err)
+		}
+	}
+	return titleTemplate, bodyTemplate, nil
+}
+
+// Check that the configured pull request templates can be used before running the rules
+func VerifyPullRequestTemplates() error {
+	_, _, err := parsePullRequestTemplates()
+	return err
+}
+
+// Title and markdown description of the pull request with the applied fixes
+func (v *Violations) BuildPullRequest(info PullRequestInfo) (string, string, error) {
+	titleTemplate, bodyTemplate, err := parsePullRequestTemplates()
+	if err != nil {
+		return "", "", err
+	}
+	content := templateContent{PullRequestInfo: info, DocUrl: viper.GetString("docs_url")}
 
 	for _, violation := range v.Violations {
-		if violation.AutoFixed {
-			fixed = append(fixed, violationToTemplate(violation, viper.GetString("docsurl")))
+		entry := violationToTemplate(violation, content.DocUrl)
+		content.Violations = append(content.Violations, entry)
+		if violation.Suppressed {
+			content.Suppressed = append(content.Suppressed, entry)
+		} else if violation.Baselined {
+			content.Baselined = append(content.Baselined, entry)
+		} else if violation.IsExcepted() {
+			content.Waived = append(content.Waived, entry)
+		} else if violation.AutoFixed {
+			content.Fixed = append(content.Fixed, entry)
 		} else {
-			detected = append(detected, violationToTemplate(violation, viper.GetString("docsurl")))
+			content.Detected = append(content.Detected, entry)
 		}
 	}
-	tmpl, err := template.New("site").Parse(prTemplate)
-	if err != nil {
-		panic(err)
-	}
-	var writer bytes.Buffer
-	err = tmpl.ExecuteTemplate(&writer, "site", templateContent{
-		Fixed:    fixed,
-		Detected: detected,
-		DocUrl:   viper.GetString("docsurl"),
-	})
-	if err != nil {
-		panic(err)
+	for _, ruleError := range v.Errors {
+		content.Errored = append(content.Errored, violationToTemplate(Violation{RuleId: ruleError.RuleId, Description: ruleError.Description, Source: ruleError.Source, Message: ruleError.Detail()}, content.DocUrl))
 	}
 
-	return writer.String()
+	var title bytes.Buffer
+	if err = titleTemplate.Execute(&title, content); err != nil {
+		return "", "", fmt.Errorf("Could not render the pull request title: %w", err)
+	}
+	var body bytes.Buffer
+	// The custom template if configured, it shares the definitions of the embedded one
+	if err = bodyTemplate.Execute(&body, content); err != nil {
+		return "", "", fmt.Errorf("Could not render the pull request description: %w", err)
+	}
+	return strings.TrimSpace(title.String()), body.String(), nil
 }
 
 func violationToTemplate(violation Violation, docBaseURL string) templateViolation {
 	res := templateViolation{
 		RuleId:      violation.RuleId,
 		Description: violation.Description,
+		Severity:    violation.Severity,
+		Source:      violation.Source,
+		Reason:      violation.SuppressionReason,
+		Message:     violation.Detail(),
+		Waiver:      violation.Waiver,
+		Location:    violation.Location.String(),
+		Instruction: violation.Location.Instruction,
+		Fixable:     violation.Fixable,
+		AutoFixed:   violation.AutoFixed,
+		Fingerprint: violation.Fingerprint(),
+	}
+	if violation.Exception != nil && violation.Exception.Type != "AssertionError" {
+		res.ExceptionType = violation.Exception.Type
 	}
 
 	if len(docBaseURL) > 0 {
-		u, err := url.Parse(docBaseURL)
+		u, err := GetDocURL(docBaseURL, res.RuleId)
 		if err != nil {
 			log.Warn().Err(err).Msg("Could not parse the docs url")
 			return res
 		}
-		u.Fragment = url.PathEscape(res.RuleId)
-		res.URL = u.String()
+		res.URL = u
 	}
 
 	return res
 }
+
+// Link to the section of the rule in the deployed docs
+func GetDocURL(docBaseURL, ruleId string) (string, error) {
+	u, err := url.Parse(docBaseURL)
+	if err != nil {
+		return "", err
+	}
+	u.Fragment = url.PathEscape(ruleId)
+	return u.String(), nil
+}
@@ -0,0 +1,101 @@
+package violations_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	violationTypes "github.com/coffeemakingtoaster/whale-watcher/pkg/validator/violations"
+	"github.com/spf13/viper"
+)
+
+var pullRequestViolations = violationTypes.Violations{
+	ViolationCount: 2,
+	Violations: []violationTypes.Violation{
+		{RuleId: "curl_fail", Description: "Use curl -f", Severity: "high", Message: "curl is called without -f", Fixable: true, AutoFixed: true, Location: violationTypes.Location{File: "Dockerfile", StartLine: 3, StageIndex: 0, Instruction: "RUN curl example.com"}},
+		{RuleId: "no_root", Description: "Do not run as root", Severity: "critical", Location: violationTypes.Location{File: "Dockerfile", StageIndex: -1}},
+	},
+}
+
+var pullRequestInfo = violationTypes.PullRequestInfo{
+	Dockerfile:     "Dockerfile",
+	ImageDigest:    "sha256:abc",
+	RuleSet:        "ruleset.yaml",
+	RuleSetVersion: "v1.2.0",
+	Diff:           "--- a/Dockerfile\\n+++ b/Dockerfile\\n",
+}
+
+func TestBuildPullRequestDefault(t *testing.T) {
+	defer viper.Reset()
+
+	title, body, err := pullRequestViolations.BuildPullRequest(pullRequestInfo)
+	if err != nil {
+		t.Fatalf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+	if title != "Autofixes" {
+		t.Errorf("Title mismatch: Expected Autofixes Got %s", title)
+	}
+	for _, expected := range []string{"(1 Fixed, 1 Unfixed)", "against `ruleset.yaml` (v1.2.0)", "```diff", "`curl_fail` (high): Use curl -f"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Body mismatch: Expected %q in %s", expected, body)
+		}
+	}
+}
+
+func TestBuildPullRequestCustomTemplates(t *testing.T) {
+	defer viper.Reset()
+	path := filepath.Join(t.TempDir(), "pr.tmpl")
+	content := "{{ .ImageDigest }}{{ range .Violations }}|{{ .RuleId }} {{ .Severity }} {{ .Message }} fixed={{ .AutoFixed }}{{ end }}{{ range .Detected }}\n{{ template \"list-entry\" . }}{{ end }}"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("pull_request.title", "whale watcher: {{ len .Fixed }} fixes for {{ .Dockerfile }}")
+	viper.Set("pull_request.template", path)
+
+	title, body, err := pullRequestViolations.BuildPullRequest(pullRequestInfo)
+	if err != nil {
+		t.Fatalf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+	if title != "whale watcher: 1 fixes for Dockerfile" {
+		t.Errorf("Title mismatch: Got %s", title)
+	}
+	expected := "sha256:abc|curl_fail high curl is called without -f fixed=true|no_root critical  fixed=false"
+	if !strings.HasPrefix(body, expected) || !strings.Contains(body, "`no_root` (critical): Do not run as root") {
+		t.Errorf("Body mismatch: Expected prefix %q and the list entry of no_root Got %s", expected, body)
+	}
+}
+
+func TestBuildPullRequestInvalidTemplates(t *testing.T) {
+	defer viper.Reset()
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.tmpl")
+	failing := filepath.Join(dir, "failing.tmpl")
+	if err := os.WriteFile(invalid, []byte("{{ range .Fixed }}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(failing, []byte("{{ .Unknown }}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		title    string
+		template string
+	}{
+		{"{{ .Fixed", ""},
+		{"", invalid},
+		{"", filepath.Join(dir, "missing.tmpl")},
+		{"", failing},
+	}
+	for _, c := range cases {
+		viper.Set("pull_request.title", c.title)
+		viper.Set("pull_request.template", c.template)
+		if _, _, err := pullRequestViolations.BuildPullRequest(pullRequestInfo); err == nil {
+			t.Errorf("Error mismatch: Expected an error for title %q and template %q Got nil", c.title, c.template)
+		}
+	}
+	viper.Set("pull_request.title", "{{ .Fixed")
+	viper.Set("pull_request.template", "")
+	if err := violationTypes.VerifyPullRequestTemplates(); err == nil {
+		t.Errorf("Error mismatch: Expected an error for an invalid title template Got nil")
+	}
+}
@@ -0,0 +1,141 @@
+package waivers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	WaiverStatusActive   = "active"
+	WaiverStatusExpiring = "expiring"
+	WaiverStatusExpired  = "expired"
+)
+
+type listedWaiver struct {
+	Rule          string `json:"rule"`
+	Dockerfile    string `json:"dockerfile,omitempty"`
+	Image         string `json:"image,omitempty"`
+	Owner         string `json:"owner"`
+	Justification string `json:"justification"`
+	Expires       string `json:"expires"`
+	Status        string `json:"status"`
+}
+
+func NewCommand() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "waivers",
+		Short: "Inspect waivers",
+		Long:  `Tooling for the time-boxed rule exceptions declared in the waiver file.`,
+	}
+
+	cmd.AddCommand(newListCommand())
+
+	return cmd
+}
+
+func newListCommand() *cobra.Command {
+	var format string
+	var days int
+	var expiringOnly bool
+
+	var cmd = &cobra.Command{
+		Use:   "list [flags] [waiver file]",
+		Short: "List waivers ordered by expiry date",
+		Long: `List the waivers ordered by expiry date together with their status (active, expiring or expired).
+Without an argument the configured waiver file (default: ` + DefaultWaiverPath + `) is used.
+
+Expected arguments:  [<waiver file location>]
+		`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return fmt.Errorf("List only takes at most one argument, the path for a waiver file (Got: '%s')", strings.Join(args, " "))
+			}
+			if format != "json" && format != "text" {
+				return fmt.Errorf("Unsupported format: %s (supported: 'json', 'text')", format)
+			}
+			if days < 0 {
+				return fmt.Errorf("within has to be positive (Got: %d)", days)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			waiverPath := GetWaiverPath()
+			if len(args) == 1 {
+				waiverPath = args[0]
+			}
+			waiverFile, err := Load(waiverPath)
+			if err != nil {
+				return err
+			}
+			listed := listWaivers(waiverFile, time.Now(), days, expiringOnly)
+			return writeWaiverList(os.Stdout, listed, format)
+		},
+	}
+
+	listFlags := pflag.NewFlagSet("List Options", pflag.ExitOnError)
+
+	listFlags.StringVar(&format, "format", "text", "Output format (json, text)")
+	listFlags.SetAnnotation("format", "group", []string{listFlags.Name()})
+	listFlags.IntVar(&days, "within", 30, "Waivers expiring within this many days are shown as expiring")
+	listFlags.SetAnnotation("within", "group", []string{listFlags.Name()})
+	listFlags.BoolVar(&expiringOnly, "expiring", false, "Only show expiring and expired waivers")
+	listFlags.SetAnnotation("expiring", "group", []string{listFlags.Name()})
+
+	cmd.Flags().AddFlagSet(listFlags)
+
+	return cmd
+}
+
+func listWaivers(waiverFile WaiverFile, now time.Time, days int, expiringOnly bool) []listedWaiver {
+	listed := []listedWaiver{}
+	for _, waiver := range waiverFile.SortedByExpiry() {
+		status := WaiverStatusActive
+		if waiver.IsExpired(now) {
+			status = WaiverStatusExpired
+		} else if waiver.IsExpiringWithin(now, days) {
+			status = WaiverStatusExpiring
+		}
+		if expiringOnly && status == WaiverStatusActive {
+			continue
+		}
+		listed = append(listed, listedWaiver{
+			Rule:          waiver.Rule,
+			Dockerfile:    waiver.Dockerfile,
+			Image:         waiver.Image,
+			Owner:         waiver.Owner,
+			Justification: strings.TrimSpace(waiver.Justification),
+			Expires:       waiver.Expires,
+			Status:        status,
+		})
+	}
+	return listed
+}
+
+func writeWaiverList(w io.Writer, listed []listedWaiver, format string) error {
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(listed)
+	}
+	for _, waiver := range listed {
+		scope := []string{}
+		if len(waiver.Dockerfile) > 0 {
+			scope = append(scope, "dockerfile="+waiver.Dockerfile)
+		}
+		if len(waiver.Image) > 0 {
+			scope = append(scope, "image="+waiver.Image)
+		}
+		_, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", waiver.Expires, waiver.Status, waiver.Rule, waiver.Owner, strings.Join(scope, " "), waiver.Justification)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package waivers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// Used if no waiver file is configured, it is fine for this file to not exist
+const DefaultWaiverPath = ".whale-watcher-waivers.yaml"
+
+// Format of the expiry date
+const DateFormat = "2006-01-02"
+
+// Time-boxed exception for a rule, signed off outside of the Dockerfile
+type Waiver struct {
+	// Rule id or glob on rule ids
+	Rule string `yaml:"rule"`
+	// Glob on the Dockerfile path, empty matches every Dockerfile
+	Dockerfile string `yaml:"dockerfile"`
+	// Glob on the image, empty matches every image
+	Image         string `yaml:"image"`
+	Justification string `yaml:"justification"`
+	Owner         string `yaml:"owner"`
+	// Last day (YYYY-MM-DD) the waiver is active
+	Expires string `yaml:"expires"`
+	expiry  time.Time
+}
+
+type WaiverFile struct {
+	Waivers []Waiver `yaml:"waivers"`
+}
+
+// What is validated, waivers are matched against this
+type Target struct {
+	Dockerfile string
+	Image      string
+}
+
+// Path of the waiver file as configured, falls back to the default location
+func GetWaiverPath() string {
+	if configured := viper.GetString("waivers"); len(configured) > 0 {
+		return configured
+	}
+	return DefaultWaiverPath
+}
+
+// Load the waivers at the configured location
+// A missing file at the default location means there are no waivers, an explicitly configured file has to exist
+func LoadConfigured() (WaiverFile, error) {
+	waiverPath := GetWaiverPath()
+	waiverFile, err := Load(waiverPath)
+	if errors.Is(err, os.ErrNotExist) && len(viper.GetString("waivers")) == 0 {
+		return WaiverFile{}, nil
+	}
+	return waiverFile, err
+}
+
+func Load(waiverPath string) (WaiverFile, error) {
+	data, err := os.ReadFile(waiverPath)
+	if err != nil {
+		return WaiverFile{}, err
+	}
+	waiverFile := WaiverFile{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err = decoder.Decode(&waiverFile); err != nil && !errors.Is(err, io.EOF) {
+		return WaiverFile{}, fmt.Errorf("%s: %s", waiverPath, err.Error())
+	}
+	for i := range waiverFile.Waivers {
+		if err = waiverFile.Waivers[i].verify(); err != nil {
+			return WaiverFile{}, fmt.Errorf("%s: waiver %d: %s", waiverPath, i+1, err.Error())
+		}
+	}
+	return waiverFile, nil
+}
+
+func (w *Waiver) verify() error {
+	if len(w.Rule) == 0 {
+		return errors.New("No rule set")
+	}
+	for _, pattern := range []string{w.Rule, w.Dockerfile, w.Image} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("Invalid glob '%s'", pattern)
+		}
+	}
+	if len(strings.TrimSpace(w.Justification)) == 0 {
+		return errors.New("No justification set")
+	}
+	if len(strings.TrimSpace(w.Owner)) == 0 {
+		return errors.New("No owner set")
+	}
+	expiry, err := time.ParseInLocation(DateFormat, w.Expires, time.Local)
+	if err != nil {
+		return fmt.Errorf("Invalid expiry date '%s' (expected YYYY-MM-DD)", w.Expires)
+	}
+	w.expiry = expiry
+	return nil
+}
+
+// The waiver is active until the end of the expiry date
+func (w Waiver) IsExpired(now time.Time) bool {
+	return !now.Before(w.expiry.AddDate(0, 0, 1))
+}
+
+// Expired or the last active day is within the given number of days
+func (w Waiver) IsExpiringWithin(now time.Time, days int) bool {
+	return !now.AddDate(0, 0, days).Before(w.expiry)
+}
+
+func (w Waiver) Matches(ruleId string, target Target) bool {
+	return matchGlob(w.Rule, ruleId) && matchGlob(normalizePath(w.Dockerfile), normalizePath(target.Dockerfile)) && matchGlob(w.Image, target.Image)
+}
+
+// Find the waiver for a violation of the rule
+// Active waivers take precedence over expired ones
+func (wf WaiverFile) Find(ruleId string, target Target, now time.Time) (Waiver, bool) {
+	var found *Waiver
+	for i, waiver := range wf.Waivers {
+		if !waiver.Matches(ruleId, target) {
+			continue
+		}
+		if !waiver.IsExpired(now) {
+			return waiver, true
+		}
+		if found == nil {
+			found = &wf.Waivers[i]
+		}
+	}
+	if found == nil {
+		return Waiver{}, false
+	}
+	return *found, true
+}
+
+// Waivers ordered by expiry date, the ones expiring first come first
+func (wf WaiverFile) SortedByExpiry() []Waiver {
+	sorted := slices.Clone(wf.Waivers)
+	slices.SortStableFunc(sorted, func(a, b Waiver) int {
+		return a.expiry.Compare(b.expiry)
+	})
+	return sorted
+}
+
+func matchGlob(pattern, value string) bool {
+	if len(pattern) == 0 {
+		return true
+	}
+	if pattern == value {
+		return true
+	}
+	matched, err := path.Match(pattern, value)
+	return err == nil && matched
+}
+
+func normalizePath(value string) string {
+	if len(value) == 0 {
+		return value
+	}
+	return strings.TrimPrefix(path.Clean(value), "./")
+}
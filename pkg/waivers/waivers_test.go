@@ -0,0 +1,128 @@
+package waivers_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coffeemakingtoaster/whale-watcher/pkg/waivers"
+	"github.com/spf13/viper"
+)
+
+const waiverContent = `waivers:
+  - rule: user_not_root
+    dockerfile: services/*/Dockerfile
+    justification: Legacy init system needs root
+    owner: platform-team
+    expires: 2026-06-30
+  - rule: apt_*
+    image: registry.example.com/legacy/*
+    justification: Base image is frozen
+    owner: security
+    expires: 2026-01-31
+  - rule: user_not_root
+    justification: Migration in progress
+    owner: app-team
+    expires: 2026-03-31
+`
+
+func writeWaiverFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "waivers.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func getDate(t *testing.T, date string) time.Time {
+	parsed, err := time.ParseInLocation(waivers.DateFormat, date, time.Local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func TestFindWaiver(t *testing.T) {
+	waiverFile, err := waivers.Load(writeWaiverFile(t, waiverContent))
+	if err != nil {
+		t.Fatalf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+	expected := []struct {
+		ruleId        string
+		target        waivers.Target
+		now           string
+		expectedOwner string
+		expired       bool
+	}{
+		{"user_not_root", waivers.Target{Dockerfile: "./services/api/Dockerfile"}, "2026-03-01", "platform-team", false},
+		// Active waivers take precedence over expired ones
+		{"user_not_root", waivers.Target{Dockerfile: "Dockerfile"}, "2026-03-31", "app-team", false},
+		{"user_not_root", waivers.Target{Dockerfile: "Dockerfile"}, "2026-04-01", "app-team", true},
+		{"user_not_root", waivers.Target{Dockerfile: "services/api/Dockerfile"}, "2026-04-01", "platform-team", false},
+		{"apt_cache_clean", waivers.Target{Image: "registry.example.com/legacy/app:1.0"}, "2026-01-15", "security", false},
+		{"apt_cache_clean", waivers.Target{Image: "registry.example.com/app:1.0"}, "2026-01-15", "", false},
+		{"curl_always_use_f", waivers.Target{}, "2026-01-15", "", false},
+	}
+	for _, testCase := range expected {
+		waiver, ok := waiverFile.Find(testCase.ruleId, testCase.target, getDate(t, testCase.now))
+		if ok != (len(testCase.expectedOwner) > 0) || waiver.Owner != testCase.expectedOwner {
+			t.Errorf("Waiver mismatch for %s %v: Expected '%s' Got '%s' (found: %v)", testCase.ruleId, testCase.target, testCase.expectedOwner, waiver.Owner, ok)
+			continue
+		}
+		if ok && waiver.IsExpired(getDate(t, testCase.now)) != testCase.expired {
+			t.Errorf("Expiry mismatch for %s at %s: Expected %v Got %v", testCase.ruleId, testCase.now, testCase.expired, !testCase.expired)
+		}
+	}
+}
+
+func TestSortedByExpiry(t *testing.T) {
+	waiverFile, err := waivers.Load(writeWaiverFile(t, waiverContent))
+	if err != nil {
+		t.Fatalf("Error mismatch: Expected nil Got '%s'", err.Error())
+	}
+	sorted := waiverFile.SortedByExpiry()
+	for i, expected := range []string{"security", "app-team", "platform-team"} {
+		if sorted[i].Owner != expected {
+			t.Errorf("Order mismatch at %d: Expected %s Got %s", i, expected, sorted[i].Owner)
+		}
+	}
+	if !sorted[0].IsExpiringWithin(getDate(t, "2026-01-01"), 30) || sorted[1].IsExpiringWithin(getDate(t, "2026-01-01"), 30) {
+		t.Error("Expiring mismatch: Expected only the first waiver to expire within 30 days")
+	}
+}
+
+func TestLoadInvalidWaivers(t *testing.T) {
+	expected := []struct {
+		content  string
+		expected string
+	}{
+		{"waivers:\n  - justification: a\n    owner: b\n    expires: 2026-01-01\n", "No rule set"},
+		{"waivers:\n  - rule: a\n    owner: b\n    expires: 2026-01-01\n", "No justification set"},
+		{"waivers:\n  - rule: a\n    justification: a\n    expires: 2026-01-01\n", "No owner set"},
+		{"waivers:\n  - rule: a\n    justification: a\n    owner: b\n    expires: 01/01/2026\n", "Invalid expiry date"},
+		{"waivers:\n  - rule: a\n    justification: a\n    owner: b\n    expires: 2026-01-01\n    expiry: 2026-01-01\n", "field expiry not found"},
+	}
+	for _, testCase := range expected {
+		_, err := waivers.Load(writeWaiverFile(t, testCase.content))
+		if err == nil {
+			t.Errorf("Expected error containing '%s', got nil", testCase.expected)
+			continue
+		}
+		if !strings.Contains(err.Error(), testCase.expected) {
+			t.Errorf("Error mismatch: Expected '%s' in '%s'", testCase.expected, err.Error())
+		}
+	}
+}
+
+func TestLoadConfiguredMissing(t *testing.T) {
+	defer viper.Reset()
+	if _, err := waivers.LoadConfigured(); err != nil {
+		t.Errorf("Error mismatch: Expected nil for missing default waiver file Got '%s'", err.Error())
+	}
+	viper.Set("waivers", filepath.Join(t.TempDir(), "missing.yaml"))
+	if _, err := waivers.LoadConfigured(); err == nil {
+		t.Error("Expected error for missing configured waiver file, got nil")
+	}
+}